@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	awsmetrics "github.com/giantswarm/dns-operator-aws/pkg/cloud/metrics"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
+
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/controlplane/eks/api/v1beta1"
+)
+
+// AWSManagedControlPlaneReconciler reconciles a AWSManagedControlPlane object of an EKS cluster
+type AWSManagedControlPlaneReconciler struct {
+	client.Client
+	Config
+
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=awsmanagedcontrolplanes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=awsmanagedcontrolplanes/status,verbs=get;update;patch
+
+func (r *AWSManagedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("awsmanagedcontrolplane", req.NamespacedName)
+
+	controlPlane := &ekscontrolplanev1.AWSManagedControlPlane{}
+	err := r.Get(ctx, req.NamespacedName, controlPlane)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// the metrics are usually removed when the finalizer is removed, this covers deletions
+			// while the operator was not running
+			awsmetrics.ForgetCluster("AWSManagedControlPlane", req.Namespace, req.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Fetch the Cluster.
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, controlPlane.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		log.Info("Cluster Controller has not yet set OwnerRef")
+		return reconcile.Result{}, err
+	}
+
+	log = log.WithValues("cluster", cluster.Name)
+
+	// Return early if the object or Cluster is paused.
+	if annotations.IsPaused(cluster, controlPlane) {
+		log.Info("AWSManagedControlPlane or linked Cluster is marked as paused. Won't reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	dnsReconciler := &clusterDNSReconciler{Client: r.Client, Config: r.Config, kind: "AWSManagedControlPlane"}
+	return dnsReconciler.reconcile(ctx, log, cluster, controlPlane, scope.ClusterScopeParams{
+		AWSManagedControlPlane: controlPlane,
+		ClusterName:            cluster.Name,
+	})
+}
+
+func (r *AWSManagedControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	awsmetrics.RegisterZoneCounter(zoneCounter(mgr.GetClient(), func() client.ObjectList { return &ekscontrolplanev1.AWSManagedControlPlaneList{} }))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ekscontrolplanev1.AWSManagedControlPlane{}).
+		WithOptions(r.controllerOptions()).
+		Complete(r)
+}
@@ -0,0 +1,446 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"time"
+
+	gsannotations "github.com/giantswarm/k8smetadata/pkg/annotation"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud"
+	awsmetrics "github.com/giantswarm/dns-operator-aws/pkg/cloud/metrics"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/services/route53"
+	"github.com/giantswarm/dns-operator-aws/pkg/dnsverifier"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+	"github.com/giantswarm/dns-operator-aws/pkg/record"
+	"github.com/giantswarm/dns-operator-aws/pkg/records"
+	"github.com/giantswarm/dns-operator-aws/pkg/tracing"
+
+	capa "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
+)
+
+// notReadyRequeueAfter is the delay before clusters with infrastructure which is not ready yet are reconciled again.
+const notReadyRequeueAfter = 30 * time.Second
+
+// Config holds the operator settings shared by the reconcilers.
+type Config struct {
+	ResolverRulesOwnerAccountId string
+	AssociateResolverRules      bool
+	DelegationRoleARN           string
+	DelegationSetID             string
+	DelegationSetMode           string
+	DNSSEC                      bool
+	DNSSECKMSKeyARN             string
+	DelegationVerifier          *dnsverifier.Verifier
+	ManagementClusterBaseDomain string
+	ManagementClusterName       string
+	ManagementClusterNamespace  string
+	QueryLoggingLogGroupARN     string
+	RecordTemplates             *records.Templates
+	ResolverQueryLogDestination string
+	StaticIdentityNamespace     string
+	WorkloadClusterBaseDomain   string
+
+	// MaxConcurrentReconciles is the number of workers of each controller.
+	MaxConcurrentReconciles int
+	// ResyncPeriod is the interval of reconciles of ready clusters, spread by ResyncJitter.
+	ResyncPeriod time.Duration
+	// ResyncJitter is the maximum factor added to ResyncPeriod, so clusters don't resync all at once.
+	ResyncJitter float64
+	// RetryBaseDelay and RetryMaxDelay bound the exponential backoff of failed reconciles.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// controllerOptions returns the options of the controllers. Failed reconciles are retried with
+// exponential backoff starting at seconds instead of the controller-runtime default of milliseconds,
+// most failures are AWS throttling errors which don't go away immediately.
+func (c Config) controllerOptions() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: c.MaxConcurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(c.RetryBaseDelay, c.RetryMaxDelay),
+			// overall retry limit, same as the controller-runtime default
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+		),
+	}
+}
+
+// resyncResult requeues a reconciled cluster after the resync period with jitter.
+func (c Config) resyncResult() ctrl.Result {
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: wait.Jitter(c.ResyncPeriod, c.ResyncJitter),
+	}
+}
+
+// clusterDNSReconciler reconciles the DNS zone of a workload cluster independent of the CAPA
+// resource describing its infrastructure.
+type clusterDNSReconciler struct {
+	client.Client
+	Config
+
+	// kind is the kind of the CAPA resource, used in log messages
+	kind string
+}
+
+// reconcile creates the scopes for the workload cluster described by params and reconciles its DNS zone.
+func (r *clusterDNSReconciler) reconcile(ctx context.Context, log logr.Logger, cluster *capi.Cluster, infraCluster cloud.ClusterObject, params scope.ClusterScopeParams) (_ ctrl.Result, err error) {
+	ctx, span := tracing.StartSpan(ctx, r.kind+".Reconcile",
+		attribute.String("kind", r.kind),
+		attribute.String("namespace", infraCluster.GetNamespace()),
+		attribute.String("name", infraCluster.GetName()),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	identityResolver := &scope.IdentityResolver{
+		Client:          r.Client,
+		SecretNamespace: r.StaticIdentityNamespace,
+	}
+
+	// Fetch bastion IP
+	// bastion might not exist depending on cluster configuration so there can be empty string here
+	var bastionIP, bastionIPv6 string
+	{
+		// invalid annotations are reported when creating the cluster scope, deleted clusters are
+		// cleaned up with the annotations which could be parsed
+		clusterAnnotations, _ := scope.ParseClusterAnnotations(infraCluster.GetAnnotations())
+
+		addrType := "ExternalIP"
+		// if the cluster is private, use the InternalIP instead of ExernalIP
+		if clusterAnnotations.PrivateVPC {
+			addrType = "InternalIP"
+		}
+
+		bastionMachineList := &capi.MachineList{}
+		err = r.List(ctx, bastionMachineList, client.MatchingLabels{
+			"cluster.x-k8s.io/cluster-name": cluster.Name,
+			"cluster.x-k8s.io/role":         "bastion",
+		},
+		)
+
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(bastionMachineList.Items) > 0 {
+			for _, addr := range bastionMachineList.Items[0].Status.Addresses {
+				if addr.Type != capi.MachineAddressType(addrType) {
+					continue
+				}
+				ip := net.ParseIP(addr.Address)
+				if ip == nil {
+					continue
+				}
+				if ip.To4() != nil {
+					if bastionIP == "" {
+						bastionIP = addr.Address
+					}
+				} else if bastionIPv6 == "" {
+					bastionIPv6 = addr.Address
+				}
+			}
+		}
+	}
+
+	// Create the workload cluster scope.
+	params.AssociateResolverRules = r.AssociateResolverRules
+	params.BaseDomain = r.WorkloadClusterBaseDomain
+	params.BastionIP = bastionIP
+	params.BastionIPv6 = bastionIPv6
+	params.DelegationSetID = r.DelegationSetID
+	params.DelegationSetMode = r.DelegationSetMode
+	params.DNSSEC = r.DNSSEC
+	params.DNSSECKMSKeyARN = r.DNSSECKMSKeyARN
+	params.IdentityResolver = identityResolver
+	params.Logger = log
+	params.ManagementClusterName = r.ManagementClusterName
+	params.QueryLoggingLogGroupARN = r.QueryLoggingLogGroupARN
+	params.RecordTemplates = r.RecordTemplates
+	params.ResolverQueryLogDestination = r.ResolverQueryLogDestination
+	params.ResolverRulesOwnerAccountId = r.ResolverRulesOwnerAccountId
+	clusterScope, err := scope.NewClusterScope(ctx, params)
+	if err != nil {
+		return r.scopeError(ctx, log, infraCluster, errors.Wrap(err, "failed to create scope"))
+	}
+
+	var managementAWSCluster capa.AWSCluster
+	err = r.Get(ctx, client.ObjectKey{Name: r.ManagementClusterName, Namespace: r.ManagementClusterNamespace}, &managementAWSCluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Error(err, "failed to get AWSCluster CR for management cluster, exiting")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Create the management cluster scope.
+	managementScope, err := scope.NewManagementClusterScope(ctx, scope.ManagementClusterScopeParams{
+		BaseDomain:       r.ManagementClusterBaseDomain,
+		DelegationARN:    r.DelegationRoleARN,
+		IdentityResolver: identityResolver,
+		Logger:           log,
+		AWSCluster:       &managementAWSCluster,
+	})
+	if err != nil {
+		return r.scopeError(ctx, log, infraCluster, errors.Wrap(err, "failed to create management cluster scope"))
+	}
+
+	// Handle deleted clusters
+	if !infraCluster.GetDeletionTimestamp().IsZero() {
+		return r.reconcileDelete(ctx, clusterScope, managementScope)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, clusterScope, managementScope)
+}
+
+func (r *clusterDNSReconciler) reconcileNormal(ctx context.Context, clusterScope *scope.ClusterScope, managementScope *scope.ManagementClusterScope) (reconcile.Result, error) {
+	clusterScope.Logger().Info("Reconciling " + r.kind + " normal")
+
+	infraCluster := clusterScope.InfraCluster()
+
+	if !controllerutil.ContainsFinalizer(infraCluster, key.DNSFinalizerName) {
+		patchHelper, err := patch.NewHelper(infraCluster, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.AddFinalizer(infraCluster, key.DNSFinalizerName)
+		err = patchHelper.Patch(ctx, infraCluster)
+		if err != nil {
+			clusterScope.Logger().Error(err, "failed to add finalizer on "+r.kind)
+			return ctrl.Result{}, err
+		}
+		clusterScope.Logger().Info("successfully added finalizer to " + r.kind)
+	}
+
+	// the helper is created before reconciling, so the hosted zone ID annotation and the conditions
+	// set below are part of the patch
+	patchHelper, err := patch.NewHelper(infraCluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	route53Service := route53.NewService(clusterScope, managementScope)
+	err = route53Service.ReconcileRoute53(ctx)
+	for i := 0; i < route53Service.DelegationDrift(); i++ {
+		awsmetrics.CaptureDelegationDrift(r.kind, infraCluster.GetNamespace(), infraCluster.GetName(), awsmetrics.DelegationCheckRecord)
+	}
+	// the migration condition is only set for clusters which were migrated to another DNS mode
+	if step, completed := route53Service.ModeMigration(); completed {
+		conditions.MarkTrue(infraCluster, key.DNSModeMigrated)
+	} else if step != "" && err != nil {
+		severity := capi.ConditionSeverityWarning
+		if step == key.MigrationNotAllowedReason {
+			severity = capi.ConditionSeverityError
+		}
+		conditions.MarkFalse(infraCluster, key.DNSModeMigrated, step, severity, "%s", err.Error())
+	}
+	if err != nil {
+		return r.reconcileError(ctx, clusterScope.Logger(), infraCluster, patchHelper, err)
+	}
+
+	// the time to DNS ready is observed once, when the zone of the cluster becomes ready for the first time,
+	// whatever failed before. Clusters which were ready before the time was recorded are not observed.
+	if !clusterScope.ZoneFirstReady() {
+		if !conditions.IsTrue(infraCluster, key.DNSZoneReady) {
+			awsmetrics.CaptureDNSReady(infraCluster.GetCreationTimestamp().Time)
+		}
+		clusterScope.SetZoneFirstReady(time.Now())
+	}
+	conditions.MarkTrue(infraCluster, key.DNSZoneReady)
+
+	if r.DelegationVerifier != nil && !clusterScope.PrivateZone() {
+		err := route53Service.VerifyDelegation(ctx, r.DelegationVerifier)
+		if dnsverifier.IsMismatch(err) {
+			clusterScope.Logger().Info("DNS delegation does not match the workload cluster zone", "reason", err.Error())
+			conditions.MarkFalse(infraCluster, key.DNSDelegationVerified, key.DelegationMismatchReason, capi.ConditionSeverityWarning, "%s", err.Error())
+			awsmetrics.CaptureDelegationDrift(r.kind, infraCluster.GetNamespace(), infraCluster.GetName(), awsmetrics.DelegationCheckNameServers)
+		} else if err != nil {
+			clusterScope.Logger().Error(err, "failed to verify DNS delegation")
+			conditions.MarkFalse(infraCluster, key.DNSDelegationVerified, key.DelegationVerificationFailedReason, capi.ConditionSeverityWarning, "%s", err.Error())
+		} else {
+			conditions.MarkTrue(infraCluster, key.DNSDelegationVerified)
+		}
+	}
+
+	err = patchHelper.Patch(ctx, infraCluster)
+	if err != nil {
+		clusterScope.Logger().Error(err, "failed to set DNSZoneReady condition")
+		return ctrl.Result{}, err
+	}
+
+	awsmetrics.CaptureReconcileSuccess(r.kind, infraCluster.GetNamespace(), infraCluster.GetName())
+	if count, ok := route53Service.RecordCount(); ok {
+		awsmetrics.CaptureZoneRecords(r.kind, infraCluster.GetNamespace(), infraCluster.GetName(), count)
+	}
+
+	return r.resyncResult(), nil
+}
+
+func (r *clusterDNSReconciler) reconcileDelete(ctx context.Context, clusterScope *scope.ClusterScope, managementScope *scope.ManagementClusterScope) (reconcile.Result, error) {
+	clusterScope.Logger().Info("Reconciling " + r.kind + " delete")
+
+	patchHelper, err := patch.NewHelper(clusterScope.InfraCluster(), r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	route53Service := route53.NewService(clusterScope, managementScope)
+
+	if err := route53Service.DeleteRoute53(ctx); err != nil {
+		return r.reconcileError(ctx, clusterScope.Logger(), clusterScope.InfraCluster(), patchHelper, err)
+	}
+	awsmetrics.ForgetCluster(r.kind, clusterScope.InfraCluster().GetNamespace(), clusterScope.InfraCluster().GetName())
+
+	clusterScope.Logger().Info("removing finalizer")
+	infraCluster, ok := clusterScope.InfraCluster().DeepCopyObject().(client.Object)
+	if !ok {
+		return reconcile.Result{}, errors.Errorf("unexpected %s object", r.kind)
+	}
+	err = r.Get(ctx, client.ObjectKeyFromObject(clusterScope.InfraCluster()), infraCluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	// Infrastructure object is deleted so remove the finalizer.
+	if controllerutil.ContainsFinalizer(infraCluster, key.DNSFinalizerName) {
+		patchHelper, err := patch.NewHelper(infraCluster, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(infraCluster, key.DNSFinalizerName)
+		err = patchHelper.Patch(ctx, infraCluster)
+		if err != nil {
+			clusterScope.Logger().Error(err, "failed to remove finalizer from "+r.kind)
+			return ctrl.Result{}, err
+		}
+		clusterScope.Logger().Info("successfully removed finalizer from " + r.kind)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// scopeError reports errors creating the scopes of a cluster like errors of the reconcile, e.g. an
+// identity which doesn't allow the namespace of the cluster is reported as access denied.
+func (r *clusterDNSReconciler) scopeError(ctx context.Context, log logr.Logger, infraCluster cloud.ClusterObject, err error) (ctrl.Result, error) {
+	patchHelper, patchErr := patch.NewHelper(infraCluster, r.Client)
+	if patchErr != nil {
+		return ctrl.Result{}, patchErr
+	}
+	return r.reconcileError(ctx, log, infraCluster, patchHelper, err)
+}
+
+// reconcileError reports the failed reconcile in the DNSZoneReady condition and decides how it is retried
+// based on the class of the error:
+//   - throttling, conflicts and unknown errors are retried with exponential backoff
+//   - errors which need user action, like missing permissions or invalid configuration, are retried
+//     with the resync period and reported in a warning event
+//   - not ready infrastructure is checked again shortly without backoff
+func (r *clusterDNSReconciler) reconcileError(ctx context.Context, log logr.Logger, infraCluster cloud.ClusterObject, patchHelper *patch.Helper, reconcileErr error) (ctrl.Result, error) {
+	class := route53.ClassifyError(reconcileErr)
+	awsmetrics.CaptureReconcileError(r.kind, string(class))
+	awsmetrics.CaptureReconcileFailure(r.kind, infraCluster.GetNamespace(), infraCluster.GetName(), string(class))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("error.class", string(class)))
+	trace.SpanFromContext(ctx).RecordError(reconcileErr)
+	if class == route53.ErrorClassNotReady {
+		log.Info("waiting for infrastructure", "reason", reconcileErr.Error())
+	} else {
+		log.Error(reconcileErr, "failed to reconcile route53", "class", class)
+	}
+
+	reason, severity := key.ReconcileFailedReason, capi.ConditionSeverityWarning
+	switch class {
+	case route53.ErrorClassThrottled:
+		reason = key.AWSThrottledReason
+	case route53.ErrorClassAccessDenied:
+		reason, severity = key.AWSAccessDeniedReason, capi.ConditionSeverityError
+	case route53.ErrorClassInvalidInput:
+		reason, severity = key.InvalidConfigurationReason, capi.ConditionSeverityError
+	case route53.ErrorClassNotReady:
+		reason, severity = key.WaitingForInfrastructureReason, capi.ConditionSeverityInfo
+	case route53.ErrorClassConflict:
+		reason = key.ConflictReason
+	}
+	conditions.MarkFalse(infraCluster, key.DNSZoneReady, reason, severity, "%s", reconcileErr.Error())
+	if err := patchHelper.Patch(ctx, infraCluster); err != nil {
+		log.Error(err, "failed to set DNSZoneReady condition")
+	}
+
+	switch class {
+	case route53.ErrorClassNotReady:
+		return ctrl.Result{RequeueAfter: notReadyRequeueAfter}, nil
+	case route53.ErrorClassAccessDenied, route53.ErrorClassInvalidInput:
+		record.Warnf(infraCluster, reason, "Failed to reconcile DNS zone: %s", reconcileErr.Error())
+		return r.resyncResult(), nil
+	default:
+		return ctrl.Result{}, reconcileErr
+	}
+}
+
+// zoneCounter counts the infrastructure clusters of a kind with a zone managed by the operator, i.e. with
+// the DNS finalizer, per DNS mode. The clusters are listed from the cache of the manager.
+func zoneCounter(c client.Reader, newList func() client.ObjectList) awsmetrics.ZoneCounter {
+	return func(ctx context.Context) (map[string]int, error) {
+		list := newList()
+		err := c.List(ctx, list)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		counts := map[string]int{}
+		for _, o := range objects {
+			obj, ok := o.(client.Object)
+			if !ok || !controllerutil.ContainsFinalizer(obj, key.DNSFinalizerName) {
+				continue
+			}
+			if obj.GetAnnotations()[gsannotations.AWSDNSMode] == gsannotations.DNSModePrivate {
+				counts[awsmetrics.ZoneModePrivate]++
+			} else {
+				counts[awsmetrics.ZoneModePublic]++
+			}
+		}
+		return counts, nil
+	}
+}
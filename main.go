@@ -17,19 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	capa "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/controlplane/eks/api/v1beta1"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	"github.com/giantswarm/dns-operator-aws/controllers"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
+	"github.com/giantswarm/dns-operator-aws/pkg/dnsverifier"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+	"github.com/giantswarm/dns-operator-aws/pkg/record"
+	"github.com/giantswarm/dns-operator-aws/pkg/records"
+	"github.com/giantswarm/dns-operator-aws/pkg/tracing"
+	"github.com/giantswarm/dns-operator-aws/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -42,6 +55,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = capa.AddToScheme(scheme)
+	_ = ekscontrolplanev1.AddToScheme(scheme)
 	_ = capi.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
@@ -49,9 +63,38 @@ func init() {
 func main() {
 	var (
 		associateResolverRules      bool
+		awsOperationTimeouts        string
+		awsRequestTimeout           time.Duration
 		resolverRulesOwnerAccountId string
+		delegationRoleARN           string
+		delegationSetID             string
+		delegationSetMode           string
+		dnssec                      bool
+		dnssecKMSKeyARN             string
+		enableEKS                   bool
+		enableWebhook               bool
 		enableLeaderElection        bool
+		healthProbeAddr             string
+		maxConcurrentReconciles     int
 		metricsAddr                 string
+		queryLoggingLogGroupARN     string
+		readinessAWSCheck           bool
+		readinessAWSCheckInterval   time.Duration
+		recordTemplatesFile         string
+		resolverQueryLogDestination string
+		resyncJitter                float64
+		resyncPeriod                time.Duration
+		retryBaseDelay              time.Duration
+		retryMaxDelay               time.Duration
+		route53RateBurst            int
+		route53RateLimit            float64
+		staticIdentityNamespace     string
+		tracingEndpoint             string
+		tracingSampleRatio          float64
+		verifyDelegation            bool
+		verificationNameserverPort  string
+		verificationResolvers       string
+		verificationTimeout         time.Duration
 		workloadClusterBaseDomain   string
 		managementClusterBaseDomain string
 		managementClusterName       string
@@ -60,51 +103,209 @@ func main() {
 	flag.BoolVar(&associateResolverRules, "associate-resolver-rules", false,
 		"Enable associating all resolver rules in aws account to the workload cluster VPC "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&awsRequestTimeout, "aws-request-timeout", scope.DefaultAWSRequestTimeout, "Timeout of a single AWS operation including its retries. Disabled with 0.")
+	flag.StringVar(&awsOperationTimeouts, "aws-operation-timeouts", "", "Comma separated timeouts overriding --aws-request-timeout per AWS operation, e.g. CreateHostedZone=1m,ListResourceRecordSets=45s.")
+	flag.BoolVar(&enableEKS, "enable-eks", false, "Reconcile the DNS zones of EKS clusters described by AWSManagedControlPlane resources.")
+	flag.BoolVar(&enableWebhook, "enable-webhook", false, "Serve the validating webhook of the DNS annotations of AWSClusters on port 9443.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "Number of clusters reconciled concurrently by each controller.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 5*time.Minute, "Interval of reconciles of ready clusters.")
+	flag.Float64Var(&resyncJitter, "resync-jitter", 0.1, "Maximum factor of the resync period added as random jitter, so clusters don't resync all at once.")
+	flag.DurationVar(&retryBaseDelay, "retry-base-delay", 5*time.Second, "Initial delay before a failed reconcile is retried, doubled with every failure.")
+	flag.DurationVar(&retryMaxDelay, "retry-max-delay", 10*time.Minute, "Maximum delay before a failed reconcile is retried.")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&healthProbeAddr, "health-probe-bind-address", ":8081", "The address the liveness and readiness probe endpoints bind to.")
+	flag.BoolVar(&readinessAWSCheck, "readiness-aws-check", false, "Report the operator as not ready when STS and Route53 are not reachable with its own credentials in the MANAGEMENT_CLUSTER_REGION.")
+	flag.DurationVar(&readinessAWSCheckInterval, "readiness-aws-check-interval", time.Minute, "Interval of the AWS reachability checks, probes in between get the cached result.")
 
 	flag.StringVar(&workloadClusterBaseDomain, "workload-cluster-basedomain", "", "Domain for workload cluster, e.g. installation.eu-west-1.aws.domain.tld")
 	flag.StringVar(&managementClusterBaseDomain, "management-cluster-basedomain", "", "Domain for management cluster, e.g. installation.eu-west-1.aws.domain.tld.")
 	flag.StringVar(&managementClusterName, "management-cluster-name", "", "Management cluster CR name.")
 	flag.StringVar(&managementClusterNamespace, "management-cluster-namespace", "", "Management cluster CR namespace.")
+	flag.StringVar(&delegationRoleARN, "delegation-role-arn", "", "ARN of the role used to manage NS records in the parent zone of workload cluster zones. Defaults to the management cluster role.")
+	flag.StringVar(&delegationSetID, "delegation-set-id", "", "ID of an existing reusable delegation set used for all public workload cluster zones.")
+	flag.StringVar(&delegationSetMode, "delegation-set-mode", "", "Create reusable delegation sets for public workload cluster zones per \"installation\" or per \"cluster\". Disabled when empty.")
+	flag.BoolVar(&dnssec, "enable-dnssec", false, "Enable DNSSEC signing for public workload cluster zones. Can be overridden per cluster with the aws.giantswarm.io/dns-dnssec annotation.")
+	flag.StringVar(&dnssecKMSKeyARN, "dnssec-kms-key-arn", "", "ARN of the KMS key in us-east-1 backing the DNSSEC key signing keys.")
+	flag.StringVar(&queryLoggingLogGroupARN, "query-logging-log-group-arn", "", "ARN of the CloudWatch Logs log group in us-east-1 receiving the query logs of public workload cluster zones. Disabled when empty.")
+	flag.StringVar(&resolverQueryLogDestination, "resolver-query-logging-destination-arn", "", "ARN of the destination receiving the Route53 Resolver query logs of private workload cluster VPCs. Disabled when empty.")
+	flag.StringVar(&recordTemplatesFile, "record-templates-file", "", "Path to a YAML file with the templates of the records created for workload clusters. Defaults to the built-in templates.")
+	flag.Float64Var(&route53RateLimit, "route53-rate-limit", scope.DefaultRoute53RateLimit, "Route53 requests per second per AWS account, shared by all clusters using the account.")
+	flag.IntVar(&route53RateBurst, "route53-rate-burst", scope.DefaultRoute53RateBurst, "Route53 requests per AWS account allowed to exceed the rate limit at once.")
+	flag.StringVar(&staticIdentityNamespace, "static-identity-namespace", "giantswarm", "Namespace of the secrets referenced by AWSClusterStaticIdentity resources.")
 	flag.StringVar(&resolverRulesOwnerAccountId, "account-id", "", "AWS account id owner of the dns resolver rules that will be associated with the VPC.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "OTLP HTTP endpoint receiving the traces of reconciles and AWS requests, e.g. http://otel-collector:4318. Disabled when empty.")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1, "Ratio of reconciles which are traced.")
+	flag.BoolVar(&verifyDelegation, "verify-delegation", false, "Verify public zone delegations by querying the authoritative name servers of the parent zone.")
+	flag.StringVar(&verificationNameserverPort, "verification-nameserver-port", "53", "Port used to query authoritative name servers during delegation verification.")
+	flag.StringVar(&verificationResolvers, "verification-resolvers", "", "Comma separated list of resolver addresses (host:port) used to look up name servers during delegation verification. Defaults to the system resolver.")
+	flag.DurationVar(&verificationTimeout, "verification-timeout", 5*time.Second, "Timeout of a single DNS query during delegation verification.")
+
+	// logs are JSON encoded at info level unless configured otherwise with the --zap-* flags,
+	// e.g. --zap-devel for console encoded debug logs during development
+	logOptions := zap.Options{}
+	logOptions.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&logOptions)))
+
+	switch delegationSetMode {
+	case "", key.DelegationSetModeCluster, key.DelegationSetModeInstallation:
+	default:
+		setupLog.Error(errors.Errorf("unknown delegation set mode %q", delegationSetMode), "invalid flags")
+		os.Exit(1)
+	}
+	if dnssec && dnssecKMSKeyARN == "" {
+		setupLog.Error(errors.New("--dnssec-kms-key-arn must be set when DNSSEC is enabled"), "invalid flags")
+		os.Exit(1)
+	}
+	if route53RateLimit <= 0 || route53RateBurst < 1 {
+		setupLog.Error(errors.New("--route53-rate-limit and --route53-rate-burst must be positive"), "invalid flags")
+		os.Exit(1)
+	}
+	scope.SetRoute53RateLimit(route53RateLimit, route53RateBurst)
+	if maxConcurrentReconciles < 1 || resyncPeriod <= 0 || resyncJitter < 0 || retryBaseDelay <= 0 || retryMaxDelay < retryBaseDelay {
+		setupLog.Error(errors.New("--max-concurrent-reconciles, --resync-period and --retry-base-delay must be positive, --resync-jitter must not be negative and --retry-max-delay must not be less than --retry-base-delay"), "invalid flags")
+		os.Exit(1)
+	}
+
+	operationTimeouts, err := scope.ParseOperationTimeouts(awsOperationTimeouts)
+	if err != nil {
+		setupLog.Error(err, "invalid flags")
+		os.Exit(1)
+	}
+	scope.SetAWSRequestTimeouts(awsRequestTimeout, operationTimeouts)
+
+	recordTemplates := records.DefaultTemplates()
+	if recordTemplatesFile != "" {
+		recordTemplates, err = records.LoadTemplates(recordTemplatesFile)
+		if err != nil {
+			setupLog.Error(err, "invalid record templates")
+			os.Exit(1)
+		}
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:             scheme,
-		MetricsBindAddress: metricsAddr,
-		Port:               9443,
-		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   "d43d4591.giantswarm.io",
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: healthProbeAddr,
+		Port:                   9443,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "d43d4591.giantswarm.io",
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.AWSClusterReconciler{
-		Client:                      mgr.GetClient(),
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	if readinessAWSCheck {
+		awsCheck, err := scope.NewAWSReachabilityCheck(os.Getenv("MANAGEMENT_CLUSTER_REGION"), readinessAWSCheckInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to set up AWS ready check")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("aws", awsCheck.Check); err != nil {
+			setupLog.Error(err, "unable to set up AWS ready check")
+			os.Exit(1)
+		}
+	}
+
+	var delegationVerifier *dnsverifier.Verifier
+	if verifyDelegation {
+		var resolvers []string
+		if verificationResolvers != "" {
+			resolvers = strings.Split(verificationResolvers, ",")
+		}
+		delegationVerifier = dnsverifier.New(dnsverifier.Config{
+			NameserverPort: verificationNameserverPort,
+			Resolvers:      resolvers,
+			Timeout:        verificationTimeout,
+		})
+	}
+
+	config := controllers.Config{
 		ResolverRulesOwnerAccountId: resolverRulesOwnerAccountId,
 		AssociateResolverRules:      associateResolverRules,
-		Log:                         ctrl.Log.WithName("controllers").WithName("AWSCluster"),
+		DelegationRoleARN:           delegationRoleARN,
+		DelegationSetID:             delegationSetID,
+		DelegationSetMode:           delegationSetMode,
+		DNSSEC:                      dnssec,
+		DNSSECKMSKeyARN:             dnssecKMSKeyARN,
+		DelegationVerifier:          delegationVerifier,
 		ManagementClusterBaseDomain: managementClusterBaseDomain,
 		ManagementClusterName:       managementClusterName,
 		ManagementClusterNamespace:  managementClusterNamespace,
+		QueryLoggingLogGroupARN:     queryLoggingLogGroupARN,
+		RecordTemplates:             recordTemplates,
+		ResolverQueryLogDestination: resolverQueryLogDestination,
+		StaticIdentityNamespace:     staticIdentityNamespace,
 		WorkloadClusterBaseDomain:   workloadClusterBaseDomain,
-		Scheme:                      mgr.GetScheme(),
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		ResyncPeriod:            resyncPeriod,
+		ResyncJitter:            resyncJitter,
+		RetryBaseDelay:          retryBaseDelay,
+		RetryMaxDelay:           retryMaxDelay,
+	}
+
+	record.InitFromRecorder(mgr.GetEventRecorderFor("dns-operator-aws"))
+
+	if err = (&controllers.AWSClusterReconciler{
+		Client: mgr.GetClient(),
+		Config: config,
+		Log:    ctrl.Log.WithName("controllers").WithName("AWSCluster"),
+		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AWSCluster")
 		os.Exit(1)
 	}
+	if enableEKS {
+		if err = (&controllers.AWSManagedControlPlaneReconciler{
+			Client: mgr.GetClient(),
+			Config: config,
+			Log:    ctrl.Log.WithName("controllers").WithName("AWSManagedControlPlane"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AWSManagedControlPlane")
+			os.Exit(1)
+		}
+	}
+	if enableWebhook {
+		if err = (&webhook.AWSClusterValidator{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AWSCluster")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingEndpoint, tracingSampleRatio)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+
+	// flush the remaining spans
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		setupLog.Error(err, "failed to shut down tracing")
+	}
+
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
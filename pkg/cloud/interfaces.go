@@ -1,9 +1,14 @@
 package cloud
 
 import (
+	"time"
+
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/records"
 )
 
 // Session represents an AWS session
@@ -23,29 +28,72 @@ type ClusterScoper interface {
 	// Logger retrieves the logger
 	Logger() logr.Logger
 
-	// ARN returns the workload cluster assumed role to operate.
-	ARN() string
+	// Credentials returns the workload cluster credentials to operate, nil for the controller's own credentials.
+	Credentials() *credentials.Credentials
 	// AssociateResolverRules enables assigning all resolver rules to workload cluster VPC
 	AssociateResolverRules() bool
 	// APIEndpoint returns the AWS infrastructure Kubernetes LoadBalancer API endpoint.
 	// e.g. apiserver-x.eu-central-1.elb.amazonaws.com
 	APIEndpoint() string
+	// APIEndpointPort returns the port of the Kubernetes API endpoint.
+	APIEndpointPort() int32
+	// APIHealthCheck returns true if Route53 health checks should be created for the routed API records
+	APIHealthCheck() bool
+	// APIRecordCNAME returns true if the `api` record is a CNAME instead of an alias to the control plane load balancer
+	APIRecordCNAME() bool
+	// APIRoutingPolicy returns the routing policy between the primary and secondary API endpoint, empty for a simple record
+	APIRoutingPolicy() string
 	// BaseDomain returns workload cluster domain. This could be the same domain like management cluster or something a different one.
 	BaseDomain() string
 	// BastionIP returns IP for workload cluster bastion machine
 	BastionIP() string
+	// BastionIPv6 returns the IPv6 address for workload cluster bastion machine
+	BastionIPv6() string
+	// DelegationSetID returns the ID of an existing reusable delegation set which should be used for the hosted zone.
+	DelegationSetID() string
+	// DelegationSetReference returns the caller reference of the reusable delegation set managed by the operator,
+	// empty if reusable delegation sets are not managed.
+	DelegationSetReference() string
+	// DNSModeMigration returns true if the zone of the previous DNS mode should be replaced by a zone of the current mode
+	DNSModeMigration() bool
+	// DualStack returns true if `AAAA` records should be created for the workload cluster
+	DualStack() bool
+	// HostedZoneID returns the workload cluster hosted zone ID persisted on the infrastructure cluster
+	HostedZoneID() string
+	// SetHostedZoneID persists the workload cluster hosted zone ID on the infrastructure cluster
+	SetHostedZoneID(string)
+	// ManagedRecords returns the records created from the record templates as `<type> <name>`
+	ManagedRecords() []string
+	// SetManagedRecords persists the records created from the record templates on the infrastructure cluster
+	SetManagedRecords([]string)
+	// DNSSECDisableAfter returns the time after which DNSSEC signing can be disabled, false if the DS record wasn't removed by the operator
+	DNSSECDisableAfter() (time.Time, bool)
+	// SetDNSSECDisableAfter persists the time after which DNSSEC signing can be disabled on the infrastructure cluster
+	SetDNSSECDisableAfter(time.Time)
+	// DNSSEC returns true if the public route53 Zone should be signed with DNSSEC
+	DNSSEC() bool
+	// DNSSECKMSKeyARN returns the ARN of the KMS key backing the DNSSEC key signing key
+	DNSSECKMSKeyARN() string
 	// InfraCluster returns the AWS infrastructure cluster object.
 	InfraCluster() ClusterObject
 	// Name returns the CAPI cluster name.
 	Name() string
 	// PrivateZone returns true if the desired route53 Zone should be private
 	PrivateZone() bool
+	// QueryLoggingLogGroupARN returns the CloudWatch Logs log group ARN for query logging of public zones
+	QueryLoggingLogGroupARN() string
+	// RecordTemplates returns the templates of the records created for the workload cluster
+	RecordTemplates() *records.Templates
 	// Region returns the AWS infrastructure cluster object region.
 	Region() string
-	// VPC returns the AWSCluster vpc ID
+	// ResolverQueryLogDestination returns the destination ARN for resolver query logging of private zones
+	ResolverQueryLogDestination() string
+	// VPC returns the workload cluster vpc ID
 	VPC() string
 	// AdditionalVPCToAssign returns the list of extra VPC ids which should be assigned to a private hosted zone
 	AdditionalVPCToAssign() []string
+	// SecondaryAPIEndpoint returns the secondary Kubernetes LoadBalancer API endpoint, empty if there is none
+	SecondaryAPIEndpoint() string
 	// ResolverRulesCreatorAccount returns the account id to be used to filter dns rules associations
 	ResolverRulesCreatorAccount() string
 	// VPCCidr returns cidr of cluster's VPC
@@ -59,10 +107,12 @@ type ManagementClusterScoper interface {
 	// Logger retrieves the logger
 	Logger() logr.Logger
 
-	// ARN returns the management cluster assumed role to operate.
-	ARN() string
+	// Credentials returns the management cluster credentials to operate, nil for the controller's own credentials.
+	Credentials() *credentials.Credentials
 	// BaseDomain returns the management cluster domain which is used for workload cluster zone delegatation.
 	BaseDomain() string
+	// DelegationCredentials returns the credentials to operate on the parent zones of workload cluster zones.
+	DelegationCredentials() *credentials.Credentials
 	// InfraCluster returns the AWS infrastructure cluster object.
 	InfraCluster() ClusterObject
 	// Region returns the AWS infrastructure cluster object region.
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricDNSSubsystem               = "dns"
+	metricZonesKey                   = "zones"
+	metricZoneRecordsKey             = "zone_records"
+	metricReconcilesKey              = "reconciles_total"
+	metricReadyDurationKey           = "ready_duration_seconds"
+	metricDelegationDriftKey         = "delegation_drift_total"
+	metricLastSuccessfulReconcileKey = "last_successful_reconcile_timestamp_seconds"
+	metricKindLabel                  = "kind"
+	metricNamespaceLabel             = "namespace"
+	metricNameLabel                  = "name"
+	metricModeLabel                  = "mode"
+	metricCheckLabel                 = "check"
+	metricReconcileResultLabel       = "result"
+	metricReconcileResultSuccess     = "success"
+	metricReconcileResultErrorPrefix = "error_"
+)
+
+// zoneCountTimeout limits the time counting the zones takes when the metrics are collected.
+const zoneCountTimeout = 5 * time.Second
+
+const (
+	// ZoneModePublic is the mode of public workload cluster zones.
+	ZoneModePublic = "public"
+	// ZoneModePrivate is the mode of private workload cluster zones.
+	ZoneModePrivate = "private"
+
+	// DelegationCheckRecord is drift of the delegation records in the parent zone.
+	DelegationCheckRecord = "record"
+	// DelegationCheckNameServers is drift detected by querying the parent zone name servers.
+	DelegationCheckNameServers = "nameservers"
+)
+
+var (
+	dnsZones = &zonesCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("", metricDNSSubsystem, metricZonesKey),
+			"Number of workload cluster hosted zones managed by the operator",
+			[]string{metricModeLabel}, nil,
+		),
+	}
+	dnsZoneRecords = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metricDNSSubsystem,
+		Name:      metricZoneRecordsKey,
+		Help:      "Number of record sets in the workload cluster hosted zone",
+	}, []string{metricKindLabel, metricNamespaceLabel, metricNameLabel})
+	dnsReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metricDNSSubsystem,
+		Name:      metricReconcilesKey,
+		Help:      "Total number of reconciles per cluster by result, success or the error class",
+	}, []string{metricKindLabel, metricNamespaceLabel, metricNameLabel, metricReconcileResultLabel})
+	dnsReadyDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Subsystem: metricDNSSubsystem,
+		Name:      metricReadyDurationKey,
+		Help:      "Time from the creation of the infrastructure cluster until its DNS zone is ready",
+		Buckets:   prometheus.ExponentialBuckets(15, 2, 10),
+	})
+	dnsDelegationDrift = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metricDNSSubsystem,
+		Name:      metricDelegationDriftKey,
+		Help:      "Total number of detected differences between the delegation and the workload cluster zone",
+	}, []string{metricKindLabel, metricNamespaceLabel, metricNameLabel, metricCheckLabel})
+	dnsLastSuccessfulReconcile = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metricDNSSubsystem,
+		Name:      metricLastSuccessfulReconcileKey,
+		Help:      "Time of the last successful reconcile per cluster",
+	}, []string{metricKindLabel, metricNamespaceLabel, metricNameLabel})
+)
+
+func init() {
+	metrics.Registry.MustRegister(dnsZones)
+	metrics.Registry.MustRegister(dnsZoneRecords)
+	metrics.Registry.MustRegister(dnsReconciles)
+	metrics.Registry.MustRegister(dnsReadyDurationSeconds)
+	metrics.Registry.MustRegister(dnsDelegationDrift)
+	metrics.Registry.MustRegister(dnsLastSuccessfulReconcile)
+}
+
+// CaptureReconcileSuccess records a successful reconcile of the cluster.
+func CaptureReconcileSuccess(kind, namespace, name string) {
+	dnsReconciles.WithLabelValues(kind, namespace, name, metricReconcileResultSuccess).Inc()
+	dnsLastSuccessfulReconcile.WithLabelValues(kind, namespace, name).SetToCurrentTime()
+}
+
+// CaptureReconcileFailure records a failed reconcile of the cluster with the class of its error.
+func CaptureReconcileFailure(kind, namespace, name, class string) {
+	dnsReconciles.WithLabelValues(kind, namespace, name, metricReconcileResultErrorPrefix+class).Inc()
+}
+
+// CaptureZoneRecords records the number of record sets in the zone of the cluster.
+func CaptureZoneRecords(kind, namespace, name string, count int) {
+	dnsZoneRecords.WithLabelValues(kind, namespace, name).Set(float64(count))
+}
+
+// CaptureDNSReady records the time it took until the DNS zone of a cluster created at the given time became ready.
+func CaptureDNSReady(created time.Time) {
+	dnsReadyDurationSeconds.Observe(time.Since(created).Seconds())
+}
+
+// CaptureDelegationDrift counts a difference between the delegation and the workload cluster zone found by the check.
+func CaptureDelegationDrift(kind, namespace, name, check string) {
+	dnsDelegationDrift.WithLabelValues(kind, namespace, name, check).Inc()
+}
+
+// ForgetCluster removes the metrics of a deleted cluster.
+func ForgetCluster(kind, namespace, name string) {
+	labels := prometheus.Labels{metricKindLabel: kind, metricNamespaceLabel: namespace, metricNameLabel: name}
+	dnsZoneRecords.DeletePartialMatch(labels)
+	dnsReconciles.DeletePartialMatch(labels)
+	dnsDelegationDrift.DeletePartialMatch(labels)
+	dnsLastSuccessfulReconcile.DeletePartialMatch(labels)
+}
+
+// ZoneCounter returns the number of workload cluster zones per mode.
+type ZoneCounter func(ctx context.Context) (map[string]int, error)
+
+// RegisterZoneCounter adds the zones counted by the counter to the zones metric, e.g. the zones of the
+// infrastructure clusters of a kind.
+func RegisterZoneCounter(counter ZoneCounter) {
+	dnsZones.mutex.Lock()
+	defer dnsZones.mutex.Unlock()
+
+	dnsZones.counters = append(dnsZones.counters, counter)
+}
+
+// zonesCollector counts the zones with the registered counters when the metrics are collected, so
+// the zones of clusters which were not reconciled since the operator started are included.
+type zonesCollector struct {
+	desc *prometheus.Desc
+
+	mutex    sync.Mutex
+	counters []ZoneCounter
+}
+
+func (c *zonesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *zonesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	counters := c.counters
+	c.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), zoneCountTimeout)
+	defer cancel()
+
+	counts := map[string]int{ZoneModePublic: 0, ZoneModePrivate: 0}
+	for _, counter := range counters {
+		zones, err := counter(ctx)
+		if err != nil {
+			// an incomplete count would look like deleted zones, so the metric is left out
+			return
+		}
+		for mode, count := range zones {
+			counts[mode] += count
+		}
+	}
+
+	for mode, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), mode)
+	}
+}
@@ -18,12 +18,26 @@ const (
 	metricRequestCountKey    = "api_requests_total"
 	metricRequestDurationKey = "api_request_duration_seconds"
 	metricAPICallRetries     = "api_call_retries"
+	metricCacheRequestsKey   = "cache_requests_total"
+	metricRateLimiterWaitKey = "rate_limiter_wait_seconds"
+	metricThrottledKey       = "api_requests_throttled_total"
+	metricReconcileErrorsKey = "reconcile_errors_total"
 	metricServiceLabel       = "service"
 	metricRegionLabel        = "region"
 	metricOperationLabel     = "operation"
 	metricControllerLabel    = "controller"
 	metricStatusCodeLabel    = "status_code"
 	metricErrorCodeLabel     = "error_code"
+	metricCacheLabel         = "cache"
+	metricResultLabel        = "result"
+	metricClassLabel         = "class"
+)
+
+const (
+	// CacheCredentials is the cache of the assumed role credentials.
+	CacheCredentials = "credentials"
+	// CacheClients is the cache of the AWS clients.
+	CacheClients = "clients"
 )
 
 var (
@@ -43,12 +57,46 @@ var (
 		Help:      "Number of retries made against an AWS API",
 		Buckets:   []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
 	}, []string{metricControllerLabel, metricServiceLabel, metricRegionLabel, metricOperationLabel})
+	awsCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metricAWSSubsystem,
+		Name:      metricCacheRequestsKey,
+		Help:      "Total number of lookups in the AWS credentials and client caches",
+	}, []string{metricCacheLabel, metricResultLabel})
+	awsRateLimiterWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: metricAWSSubsystem,
+		Name:      metricRateLimiterWaitKey,
+		Help:      "Time AWS requests waited for the client side rate limiter",
+		Buckets:   []float64{0.001, 0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{metricServiceLabel})
+	awsThrottledRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metricAWSSubsystem,
+		Name:      metricThrottledKey,
+		Help:      "Total number of AWS requests rejected by AWS throttling",
+	}, []string{metricControllerLabel, metricServiceLabel, metricRegionLabel, metricOperationLabel})
+	awsReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: metricAWSSubsystem,
+		Name:      metricReconcileErrorsKey,
+		Help:      "Total number of failed reconciles by error class",
+	}, []string{metricControllerLabel, metricClassLabel})
 )
 
 func init() {
 	metrics.Registry.MustRegister(awsRequestCount)
 	metrics.Registry.MustRegister(awsRequestDurationSeconds)
 	metrics.Registry.MustRegister(awsCallRetries)
+	metrics.Registry.MustRegister(awsCacheRequests)
+	metrics.Registry.MustRegister(awsRateLimiterWaitSeconds)
+	metrics.Registry.MustRegister(awsThrottledRequests)
+	metrics.Registry.MustRegister(awsReconcileErrors)
+}
+
+// CaptureCacheRequest counts a hit or miss of the given cache.
+func CaptureCacheRequest(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	awsCacheRequests.WithLabelValues(cache, result).Inc()
 }
 
 func CaptureRequestMetrics(controller string) func(r *request.Request) {
@@ -71,6 +119,9 @@ func CaptureRequestMetrics(controller string) func(r *request.Request) {
 		awsRequestCount.WithLabelValues(controller, service, region, operation, statusCode, errorCode).Inc()
 		awsRequestDurationSeconds.WithLabelValues(controller, service, region, operation).Observe(duration.Seconds())
 		awsCallRetries.WithLabelValues(controller, service, region, operation).Observe(float64(r.RetryCount))
+		if request.IsErrorThrottle(r.Error) {
+			awsThrottledRequests.WithLabelValues(controller, service, region, operation).Inc()
+		}
 	}
 }
 
@@ -86,3 +137,13 @@ func endpointToService(endpoint string) string {
 	}
 	return endpoint
 }
+
+// CaptureRateLimiterWait records the time a request waited for the client side rate limiter.
+func CaptureRateLimiterWait(service string, wait time.Duration) {
+	awsRateLimiterWaitSeconds.WithLabelValues(service).Observe(wait.Seconds())
+}
+
+// CaptureReconcileError records a failed reconcile of the controller with the class of its error.
+func CaptureReconcileError(controller, class string) {
+	awsReconcileErrors.WithLabelValues(controller, class).Inc()
+}
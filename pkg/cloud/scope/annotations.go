@@ -0,0 +1,150 @@
+package scope
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	gsannotations "github.com/giantswarm/k8smetadata/pkg/annotation"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+)
+
+var (
+	vpcIDPattern           = regexp.MustCompile(`^vpc-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	delegationSetIDPattern = regexp.MustCompile(`^(/delegationset/)?N[A-Z0-9]+$`)
+)
+
+// ClusterAnnotations holds the DNS settings of a workload cluster given by annotations of its
+// infrastructure cluster.
+type ClusterAnnotations struct {
+	// PrivateZone is true if the workload cluster zone is a private hosted zone.
+	PrivateZone bool
+	// AdditionalVPCs are associated with the private hosted zone besides the workload cluster VPC.
+	AdditionalVPCs []string
+	// PrivateVPC is true if the cluster VPC is private, so its machines have no external addresses.
+	PrivateVPC bool
+
+	APIHealthCheck       bool
+	APIRoutingPolicy     string
+	APISecondaryEndpoint string
+	DelegationSetID      string
+	// DNSSEC is nil if the cluster doesn't override the operator configuration.
+	DNSSEC    *bool
+	DualStack bool
+	// DNSModeMigration allows replacing the zone when the DNS mode of an existing cluster changes.
+	DNSModeMigration bool
+}
+
+// ParseClusterAnnotations parses and validates the DNS annotations of an infrastructure cluster.
+func ParseClusterAnnotations(annotations map[string]string) (ClusterAnnotations, field.ErrorList) {
+	var parsed ClusterAnnotations
+	var errs field.ErrorList
+	path := field.NewPath("metadata", "annotations")
+
+	switch mode := annotations[gsannotations.AWSDNSMode]; mode {
+	case "", key.DNSModePublic:
+	case gsannotations.DNSModePrivate:
+		parsed.PrivateZone = true
+	default:
+		errs = append(errs, field.NotSupported(path.Key(gsannotations.AWSDNSMode), mode, []string{key.DNSModePublic, gsannotations.DNSModePrivate}))
+	}
+
+	if value, ok := annotations[gsannotations.AWSDNSAdditionalVPC]; ok && value != "" {
+		for _, vpc := range strings.Split(value, ",") {
+			vpc = strings.TrimSpace(vpc)
+			if !vpcIDPattern.MatchString(vpc) {
+				errs = append(errs, field.Invalid(path.Key(gsannotations.AWSDNSAdditionalVPC), value, "must be a comma separated list of VPC IDs like vpc-0123456789abcdef0"))
+				break
+			}
+			// additional VPCs are only associated with private zones
+			if parsed.PrivateZone {
+				parsed.AdditionalVPCs = append(parsed.AdditionalVPCs, vpc)
+			}
+		}
+	}
+
+	switch mode := annotations[gsannotations.AWSVPCMode]; mode {
+	case "", gsannotations.AWSVPCModePublic:
+	case gsannotations.AWSVPCModePrivate:
+		parsed.PrivateVPC = true
+	default:
+		errs = append(errs, field.NotSupported(path.Key(gsannotations.AWSVPCMode), mode, []string{gsannotations.AWSVPCModePublic, gsannotations.AWSVPCModePrivate}))
+	}
+
+	switch policy := annotations[key.APIRoutingPolicyAnnotation]; policy {
+	case "", key.APIRoutingPolicyFailover, key.APIRoutingPolicyWeighted:
+		parsed.APIRoutingPolicy = policy
+	default:
+		errs = append(errs, field.NotSupported(path.Key(key.APIRoutingPolicyAnnotation), policy, []string{key.APIRoutingPolicyFailover, key.APIRoutingPolicyWeighted}))
+	}
+
+	if value := annotations[key.APISecondaryEndpointAnnotation]; value != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.ToLower(value)) {
+			errs = append(errs, field.Invalid(path.Key(key.APISecondaryEndpointAnnotation), value, msg))
+		}
+		parsed.APISecondaryEndpoint = value
+	}
+
+	if value := annotations[key.DelegationSetIDAnnotation]; value != "" {
+		if !delegationSetIDPattern.MatchString(value) {
+			errs = append(errs, field.Invalid(path.Key(key.DelegationSetIDAnnotation), value, "must be a Route53 reusable delegation set ID like N0123456789ABCDEFGHIJ"))
+		}
+		parsed.DelegationSetID = value
+	}
+
+	if value, err := parseBoolAnnotation(annotations, key.APIHealthCheckAnnotation, path); err != nil {
+		errs = append(errs, err)
+	} else if value != nil {
+		parsed.APIHealthCheck = *value
+	}
+	if value, err := parseBoolAnnotation(annotations, key.DualStackAnnotation, path); err != nil {
+		errs = append(errs, err)
+	} else if value != nil {
+		parsed.DualStack = *value
+	}
+	if value, err := parseBoolAnnotation(annotations, key.DNSModeMigrationAnnotation, path); err != nil {
+		errs = append(errs, err)
+	} else if value != nil {
+		parsed.DNSModeMigration = *value
+	}
+	if value, err := parseBoolAnnotation(annotations, key.DNSSECAnnotation, path); err != nil {
+		errs = append(errs, err)
+	} else {
+		parsed.DNSSEC = value
+	}
+
+	return parsed, errs
+}
+
+// parseBoolAnnotation returns the value of a boolean annotation, nil if the annotation is not set.
+func parseBoolAnnotation(annotations map[string]string, annotation string, path *field.Path) (*bool, *field.Error) {
+	value, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, field.Invalid(path.Key(annotation), value, "must be true or false")
+	}
+	return &enabled, nil
+}
+
+// InvalidAnnotationsError is returned when the DNS annotations of an infrastructure cluster are invalid.
+type InvalidAnnotationsError struct {
+	errs field.ErrorList
+}
+
+// Error implements the Error interface.
+func (e *InvalidAnnotationsError) Error() string {
+	return "invalid annotations: " + e.errs.ToAggregate().Error()
+}
+
+// IsInvalidAnnotations returns true if the error was caused by invalid annotations of the infrastructure cluster.
+func IsInvalidAnnotations(err error) bool {
+	_, ok := errors.Cause(err).(*InvalidAnnotationsError)
+	return ok
+}
@@ -0,0 +1,75 @@
+package scope
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// cacheIdleTimeout is the time after which cached credentials and clients which weren't used are
+	// removed, e.g. the credentials of rotated static identity secrets or deleted identities.
+	cacheIdleTimeout = time.Hour
+	// cacheSweepInterval is the minimum interval between two sweeps of idle entries.
+	cacheSweepInterval = 5 * time.Minute
+)
+
+// idleCache is a concurrent cache which removes entries once they weren't used for cacheIdleTimeout.
+// Idle entries are swept while the cache is used, so no background goroutine is needed.
+type idleCache struct {
+	entries sync.Map
+
+	mutex     sync.Mutex
+	lastSweep time.Time
+}
+
+type idleCacheEntry struct {
+	value    interface{}
+	lastUsed atomic.Int64
+}
+
+func newIdleCacheEntry(value interface{}) *idleCacheEntry {
+	entry := &idleCacheEntry{value: value}
+	entry.lastUsed.Store(time.Now().UnixNano())
+	return entry
+}
+
+// load returns the cached value of the key and marks it as used.
+func (c *idleCache) load(key interface{}) (interface{}, bool) {
+	c.sweep()
+
+	e, ok := c.entries.Load(key)
+	if !ok {
+		return nil, false
+	}
+	entry := e.(*idleCacheEntry)
+	entry.lastUsed.Store(time.Now().UnixNano())
+	return entry.value, true
+}
+
+// loadOrStore returns the cached value of the key if there is one, otherwise the given value is cached and returned.
+func (c *idleCache) loadOrStore(key, value interface{}) interface{} {
+	e, _ := c.entries.LoadOrStore(key, newIdleCacheEntry(value))
+	entry := e.(*idleCacheEntry)
+	entry.lastUsed.Store(time.Now().UnixNano())
+	return entry.value
+}
+
+// sweep removes the entries which weren't used for cacheIdleTimeout, at most once per cacheSweepInterval.
+func (c *idleCache) sweep() {
+	c.mutex.Lock()
+	if time.Since(c.lastSweep) < cacheSweepInterval {
+		c.mutex.Unlock()
+		return
+	}
+	c.lastSweep = time.Now()
+	c.mutex.Unlock()
+
+	idleSince := time.Now().Add(-cacheIdleTimeout).UnixNano()
+	c.entries.Range(func(key, e interface{}) bool {
+		if e.(*idleCacheEntry).lastUsed.Load() < idleSince {
+			c.entries.Delete(key)
+		}
+		return true
+	})
+}
@@ -1,9 +1,9 @@
 package scope
 
 import (
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53resolver"
@@ -11,33 +11,38 @@ import (
 	"k8s.io/component-base/version"
 
 	"github.com/giantswarm/dns-operator-aws/pkg/cloud"
-	awsmetrics "github.com/giantswarm/dns-operator-aws/pkg/cloud/metrics"
 	"github.com/giantswarm/dns-operator-aws/pkg/record"
 )
 
 // AWSClients contains all the aws clients used by the scopes
 type AWSClients struct {
-	Route53 *route53.Route53
+	Route53         *route53.Route53
+	Route53Resolver *route53resolver.Route53Resolver
 }
 
-// NewRoute53Client creates a new Route53 API client for a given session
-func NewRoute53Client(session cloud.Session, arn string, target runtime.Object) *route53.Route53 {
-	Route53Client := route53.New(session.Session(), &aws.Config{Credentials: stscreds.NewCredentials(session.Session(), arn)})
-	Route53Client.Handlers.Build.PushFrontNamed(getUserAgentHandler())
-	Route53Client.Handlers.CompleteAttempt.PushFront(awsmetrics.CaptureRequestMetrics("dns-operator-aws"))
-	Route53Client.Handlers.Complete.PushBack(recordAWSPermissionsIssue(target))
-
-	return Route53Client
+// NewRoute53Client returns a Route53 API client for a given session and credentials,
+// nil credentials fall back to the credentials of the session.
+// The client shares the cached client of the credentials and only reports permission issues on the target.
+func NewRoute53Client(session cloud.Session, creds *credentials.Credentials, target runtime.Object) *route53.Route53 {
+	cached := cachedClients(session.Session(), creds)
+	return &route53.Route53{Client: clientForTarget(cached.Route53.Client, target)}
 }
 
-// NewRoute53ResolverClient creates a new Route53 API client for a given session
-func NewRoute53ResolverClient(session cloud.Session, arn string, target runtime.Object) *route53resolver.Route53Resolver {
-	Route53ResolverClient := route53resolver.New(session.Session(), &aws.Config{Credentials: stscreds.NewCredentials(session.Session(), arn)})
-	Route53ResolverClient.Handlers.Build.PushFrontNamed(getUserAgentHandler())
-	Route53ResolverClient.Handlers.CompleteAttempt.PushFront(awsmetrics.CaptureRequestMetrics("dns-operator-aws"))
-	Route53ResolverClient.Handlers.Complete.PushBack(recordAWSPermissionsIssue(target))
+// NewRoute53ResolverClient returns a Route53 Resolver API client for a given session and credentials,
+// nil credentials fall back to the credentials of the session.
+// The client shares the cached client of the credentials and only reports permission issues on the target.
+func NewRoute53ResolverClient(session cloud.Session, creds *credentials.Credentials, target runtime.Object) *route53resolver.Route53Resolver {
+	cached := cachedClients(session.Session(), creds)
+	return &route53resolver.Route53Resolver{Client: clientForTarget(cached.Route53Resolver.Client, target)}
+}
 
-	return Route53ResolverClient
+// clientForTarget returns a shallow copy of the client with its own handlers, which report
+// permission issues on the target.
+func clientForTarget(c *client.Client, target runtime.Object) *client.Client {
+	targetClient := *c
+	targetClient.Handlers = c.Handlers.Copy()
+	targetClient.Handlers.Complete.PushBack(recordAWSPermissionsIssue(target))
+	return &targetClient
 }
 
 func getUserAgentHandler() request.NamedHandler {
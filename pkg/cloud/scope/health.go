@@ -0,0 +1,84 @@
+package scope
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// awsCheckTimeout bounds the requests of a single AWS reachability check.
+const awsCheckTimeout = 10 * time.Second
+
+// AWSReachabilityCheck checks that STS and Route53 are reachable with the operator's own credentials.
+// The result is cached for the check interval, so frequent probes don't use up the Route53 rate limit.
+// The check bypasses the rate limiter shared by the reconciles, a check waiting for it would time out
+// while reconciles are throttled.
+type AWSReachabilityCheck struct {
+	sts     *sts.STS
+	route53 *route53.Route53
+
+	interval  time.Duration
+	mutex     sync.Mutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+// NewAWSReachabilityCheck returns a check of the AWS APIs in the region, performed at most once per interval.
+func NewAWSReachabilityCheck(region string, interval time.Duration) (*AWSReachabilityCheck, error) {
+	if region == "" {
+		return nil, errors.New("failed to create AWS reachability check for empty region")
+	}
+	session, err := sessionForRegion(region)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create aws session")
+	}
+
+	// the check doesn't wait for the account wide rate limiter, so throttled reconciles don't fail the
+	// probe and take the operator and its webhook out of service
+	route53Client := route53.New(session)
+	route53Client.Handlers.Build.PushFrontNamed(getUserAgentHandler())
+
+	return &AWSReachabilityCheck{
+		sts:      sts.New(session),
+		route53:  route53Client,
+		interval: interval,
+	}, nil
+}
+
+// Check implements healthz.Checker. Concurrent probes wait for the running check and share its result.
+// The check isn't bound to the probe request, so a probe timing out doesn't fail the cached result.
+func (c *AWSReachabilityCheck) Check(_ *http.Request) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.lastCheck.IsZero() && time.Since(c.lastCheck) < c.interval {
+		return c.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), awsCheckTimeout)
+	defer cancel()
+	c.lastErr = c.check(ctx)
+	c.lastCheck = time.Now()
+
+	return c.lastErr
+}
+
+func (c *AWSReachabilityCheck) check(ctx context.Context) error {
+	_, err := c.sts.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return errors.Wrap(err, "failed to reach STS")
+	}
+
+	_, err = c.route53.ListHostedZonesWithContext(ctx, &route53.ListHostedZonesInput{MaxItems: aws.String("1")})
+	if err != nil {
+		return errors.Wrap(err, "failed to reach Route53")
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package scope
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	infrav1 "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxSourceIdentityDepth limits the chain of source identities, so reference cycles can't loop forever.
+const maxSourceIdentityDepth = 5
+
+// keys of the static identity secret, compatible with CAPA
+const (
+	staticIdentityAccessKeyID     = "AccessKeyID"
+	staticIdentitySecretAccessKey = "SecretAccessKey"
+	staticIdentitySessionToken    = "SessionToken"
+)
+
+// IdentityResolver resolves CAPA identity references to AWS credentials.
+type IdentityResolver struct {
+	Client client.Client
+	// SecretNamespace is the namespace of the secrets referenced by AWSClusterStaticIdentity resources.
+	SecretNamespace string
+}
+
+// Credentials returns the credentials for the given identity reference of a cluster in the namespace.
+// Nil credentials mean that the controller's own credentials, e.g. from IRSA or the instance profile,
+// are used. This is the case for AWSClusterControllerIdentity and when no identity is referenced at all.
+// Like in CAPA, every identity of the chain has to allow the namespace of the cluster.
+// Credentials are cached per role and session settings, so roles are only assumed again when the
+// credentials are about to expire.
+func (r *IdentityResolver) Credentials(ctx context.Context, session awsclient.ConfigProvider, ref *infrav1.AWSIdentityReference, namespace string) (*credentials.Credentials, error) {
+	creds, _, err := r.credentials(ctx, session, ref, namespace, 0)
+	return creds, err
+}
+
+// credentials returns the credentials of the identity and the key identifying them in the cache.
+func (r *IdentityResolver) credentials(ctx context.Context, session awsclient.ConfigProvider, ref *infrav1.AWSIdentityReference, namespace string, depth int) (*credentials.Credentials, string, error) {
+	if ref == nil {
+		return nil, "", nil
+	}
+	if depth > maxSourceIdentityDepth {
+		return nil, "", errors.Errorf("failed to resolve identity %s, source identity chain is longer than %d", ref.Name, maxSourceIdentityDepth)
+	}
+
+	switch ref.Kind {
+	case infrav1.ControllerIdentityKind:
+		identity := &infrav1.AWSClusterControllerIdentity{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, identity)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get AWSClusterControllerIdentity %s", ref.Name)
+		}
+		err = r.checkNamespaceAllowed(ctx, ref, identity.Spec.AllowedNamespaces, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", nil
+
+	case infrav1.ClusterStaticIdentityKind:
+		identity := &infrav1.AWSClusterStaticIdentity{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, identity)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get AWSClusterStaticIdentity %s", ref.Name)
+		}
+		err = r.checkNamespaceAllowed(ctx, ref, identity.Spec.AllowedNamespaces, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+
+		secret := &corev1.Secret{}
+		err = r.Client.Get(ctx, client.ObjectKey{Name: identity.Spec.SecretRef, Namespace: r.SecretNamespace}, secret)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get secret %s/%s of AWSClusterStaticIdentity %s", r.SecretNamespace, identity.Spec.SecretRef, ref.Name)
+		}
+		accessKeyID := string(secret.Data[staticIdentityAccessKeyID])
+		secretAccessKey := string(secret.Data[staticIdentitySecretAccessKey])
+		sessionToken := string(secret.Data[staticIdentitySessionToken])
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, "", errors.Errorf("secret %s/%s of AWSClusterStaticIdentity %s has no access key", r.SecretNamespace, identity.Spec.SecretRef, ref.Name)
+		}
+
+		// rotated secrets result in new credentials
+		key := fmt.Sprintf("static/%x", sha256.Sum256([]byte(accessKeyID+"/"+secretAccessKey+"/"+sessionToken)))
+		creds := cachedCredentials(session, key, func() *credentials.Credentials {
+			return credentials.NewStaticCredentials(accessKeyID, secretAccessKey, sessionToken)
+		})
+		return creds, key, nil
+
+	case infrav1.ClusterRoleIdentityKind, "":
+		// references without kind predate the other identity kinds
+		identity := &infrav1.AWSClusterRoleIdentity{}
+		err := r.Client.Get(ctx, client.ObjectKey{Name: ref.Name}, identity)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get AWSClusterRoleIdentity %s", ref.Name)
+		}
+		err = r.checkNamespaceAllowed(ctx, ref, identity.Spec.AllowedNamespaces, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+		if identity.Spec.RoleArn == "" {
+			return nil, "", errors.Errorf("AWSClusterRoleIdentity %s has no role ARN", ref.Name)
+		}
+
+		// the role is assumed with the credentials of the source identity
+		source, sourceKey, err := r.credentials(ctx, session, identity.Spec.SourceIdentityRef, namespace, depth+1)
+		if err != nil {
+			return nil, "", err
+		}
+
+		key := roleCredentialsKey(identity.Spec, sourceKey)
+		creds := cachedCredentials(session, key, func() *credentials.Credentials {
+			return assumeRoleCredentials(sts.New(session, &aws.Config{Credentials: source}), identity.Spec)
+		})
+		return creds, key, nil
+
+	default:
+		return nil, "", errors.Errorf("failed to resolve identity %s of unknown kind %q", ref.Name, ref.Kind)
+	}
+}
+
+// checkNamespaceAllowed returns an IdentityNotAllowedError if the identity doesn't allow clusters of the
+// namespace, with the semantics of CAPA: nil allows no namespace, an empty value allows all namespaces,
+// otherwise the namespace has to be listed or match the selector. An empty selector matches nothing.
+func (r *IdentityResolver) checkNamespaceAllowed(ctx context.Context, ref *infrav1.AWSIdentityReference, allowed *infrav1.AllowedNamespaces, namespace string) error {
+	notAllowed := &IdentityNotAllowedError{msg: fmt.Sprintf("%s %s does not allow clusters of namespace %q", ref.Kind, ref.Name, namespace)}
+	if allowed == nil {
+		return notAllowed
+	}
+	if len(allowed.NamespaceList) == 0 && len(allowed.Selector.MatchLabels) == 0 && len(allowed.Selector.MatchExpressions) == 0 {
+		return nil
+	}
+	for _, n := range allowed.NamespaceList {
+		if n == namespace {
+			return nil
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&allowed.Selector)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse namespace selector of %s %s", ref.Kind, ref.Name)
+	}
+	if selector.Empty() {
+		return notAllowed
+	}
+	ns := &corev1.Namespace{}
+	err = r.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get namespace %s", namespace)
+	}
+	if !selector.Matches(labels.Set(ns.GetLabels())) {
+		return notAllowed
+	}
+	return nil
+}
+
+// IdentityNotAllowedError is returned when an identity does not allow clusters of the namespace.
+type IdentityNotAllowedError struct {
+	msg string
+}
+
+// Error implements the Error interface.
+func (e *IdentityNotAllowedError) Error() string {
+	return e.msg
+}
+
+// IsIdentityNotAllowed returns true if the error was caused by an identity not allowing the namespace of the cluster.
+func IsIdentityNotAllowed(err error) bool {
+	_, ok := errors.Cause(err).(*IdentityNotAllowedError)
+	return ok
+}
+
+// roleCredentialsKey identifies the credentials of a role by all settings used to assume it, so identities
+// of the same role with different session policies don't share credentials and changed identities
+// result in new credentials.
+func roleCredentialsKey(spec infrav1.AWSClusterRoleIdentitySpec, sourceKey string) string {
+	settings := fmt.Sprintf("%s/%s/%d/%s/%s", spec.ExternalID, spec.SessionName, spec.DurationSeconds, spec.InlinePolicy, strings.Join(spec.PolicyARNs, ","))
+	return fmt.Sprintf("role/%s/%x/%s", spec.RoleArn, sha256.Sum256([]byte(settings)), sourceKey)
+}
+
+// assumeRoleCredentials returns credentials of the role of the identity, which are refreshed shortly before they expire.
+func assumeRoleCredentials(client *sts.STS, spec infrav1.AWSClusterRoleIdentitySpec) *credentials.Credentials {
+	return stscreds.NewCredentialsWithClient(client, spec.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+		p.ExpiryWindow = credentialsExpiryWindow
+		if spec.ExternalID != "" {
+			p.ExternalID = aws.String(spec.ExternalID)
+		}
+		if spec.SessionName != "" {
+			p.RoleSessionName = spec.SessionName
+		}
+		if spec.DurationSeconds > 0 {
+			p.Duration = time.Duration(spec.DurationSeconds) * time.Second
+		}
+		if spec.InlinePolicy != "" {
+			p.Policy = aws.String(spec.InlinePolicy)
+		}
+		for _, arn := range spec.PolicyARNs {
+			p.PolicyArns = append(p.PolicyArns, &sts.PolicyDescriptorType{Arn: aws.String(arn)})
+		}
+	})
+}
@@ -1,9 +1,12 @@
 package scope
 
 import (
+	"context"
 	"os"
 
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	infrav1 "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
@@ -13,18 +16,19 @@ import (
 
 // ManagementClusterScopeParams defines the input parameters used to create a new Scope.
 type ManagementClusterScopeParams struct {
-	ARN        string
-	AWSCluster *infrav1.AWSCluster
-	BaseDomain string
-	Logger     logr.Logger
-	Session    awsclient.ConfigProvider
+	AWSCluster       *infrav1.AWSCluster
+	BaseDomain       string
+	DelegationARN    string
+	IdentityResolver *IdentityResolver
+	Logger           logr.Logger
+	Session          awsclient.ConfigProvider
 }
 
 // NewManagementClusterScope creates a new Scope from the supplied parameters.
 // This is meant to be called for each reconcile iteration.
-func NewManagementClusterScope(params ManagementClusterScopeParams) (*ManagementClusterScope, error) {
-	if params.ARN == "" {
-		return nil, errors.New("failed to generate new scope from emtpy string ARN")
+func NewManagementClusterScope(ctx context.Context, params ManagementClusterScopeParams) (*ManagementClusterScope, error) {
+	if params.IdentityResolver == nil {
+		return nil, errors.New("failed to generate new scope from nil IdentityResolver")
 	}
 	if params.AWSCluster == nil {
 		return nil, errors.New("failed to generate new scope from nil AWSCluster")
@@ -42,31 +46,47 @@ func NewManagementClusterScope(params ManagementClusterScopeParams) (*Management
 		return nil, errors.Wrap(err, "failed to create aws session")
 	}
 
+	creds, err := params.IdentityResolver.Credentials(ctx, session, params.AWSCluster.Spec.IdentityRef, params.AWSCluster.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve aws identity")
+	}
+
+	delegationCredentials := creds
+	if params.DelegationARN != "" {
+		spec := infrav1.AWSClusterRoleIdentitySpec{AWSRoleSpec: infrav1.AWSRoleSpec{RoleArn: params.DelegationARN}}
+		delegationCredentials = cachedCredentials(session, roleCredentialsKey(spec, ""), func() *credentials.Credentials {
+			return assumeRoleCredentials(sts.New(session), spec)
+		})
+	}
+
 	return &ManagementClusterScope{
-		assumeRole: params.ARN,
-		AWSCluster: params.AWSCluster,
-		baseDomain: params.BaseDomain,
-		logger:     params.Logger,
-		session:    session,
+		AWSCluster:            params.AWSCluster,
+		baseDomain:            params.BaseDomain,
+		credentials:           creds,
+		delegationCredentials: delegationCredentials,
+		logger:                params.Logger,
+		session:               session,
 	}, nil
 }
 
 // ManagementClusterScope defines the basic context for an actuator to operate upon.
 type ManagementClusterScope struct {
-	assumeRole string
-	AWSCluster *infrav1.AWSCluster
-	baseDomain string
-	logger     logr.Logger
-	session    awsclient.ConfigProvider
+	AWSCluster            *infrav1.AWSCluster
+	baseDomain            string
+	credentials           *credentials.Credentials
+	delegationCredentials *credentials.Credentials
+	logger                logr.Logger
+	session               awsclient.ConfigProvider
 }
 
 func (s *ManagementClusterScope) Logger() logr.Logger {
 	return s.logger
 }
 
-// ARN returns the AWS SDK assumed role. Used for creating workload cluster client.
-func (s *ManagementClusterScope) ARN() string {
-	return s.assumeRole
+// Credentials returns the AWS credentials of the management cluster identity.
+// Nil credentials mean the controller's own credentials are used.
+func (s *ManagementClusterScope) Credentials() *credentials.Credentials {
+	return s.credentials
 }
 
 // BaseDomain returns the management cluster basedomain.
@@ -74,6 +94,12 @@ func (s *ManagementClusterScope) BaseDomain() string {
 	return s.baseDomain
 }
 
+// DelegationCredentials returns the AWS credentials used to manage the parent zone of workload cluster zones.
+// They default to the management cluster credentials.
+func (s *ManagementClusterScope) DelegationCredentials() *credentials.Credentials {
+	return s.delegationCredentials
+}
+
 // InfraCluster returns the AWS infrastructure cluster or control plane object.
 func (s *ManagementClusterScope) InfraCluster() cloud.ClusterObject {
 	return s.AWSCluster
@@ -0,0 +1,89 @@
+package scope
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	awsmetrics "github.com/giantswarm/dns-operator-aws/pkg/cloud/metrics"
+)
+
+// Route53 allows 5 requests per second per AWS account.
+const (
+	DefaultRoute53RateLimit = 5
+	DefaultRoute53RateBurst = 1
+)
+
+var (
+	route53RateLimit rate.Limit = DefaultRoute53RateLimit
+	route53RateBurst            = DefaultRoute53RateBurst
+
+	// route53Limiters holds the Route53 rate limiter of each AWS account, shared by all clients of the account.
+	route53Limiters sync.Map
+)
+
+// SetRoute53RateLimit configures the Route53 requests per second and burst allowed per AWS account.
+// It has to be called before the first client is created.
+func SetRoute53RateLimit(limit float64, burst int) {
+	route53RateLimit = rate.Limit(limit)
+	route53RateBurst = burst
+}
+
+// accountRateLimiter delays the requests of a client according to the Route53 rate limiter of its AWS account.
+// The account is looked up with the credentials of the client on the first request.
+type accountRateLimiter struct {
+	session     awsclient.ConfigProvider
+	credentials *credentials.Credentials
+
+	mutex   sync.Mutex
+	limiter *rate.Limiter
+}
+
+func newAccountRateLimiter(session awsclient.ConfigProvider, creds *credentials.Credentials) *accountRateLimiter {
+	return &accountRateLimiter{
+		session:     session,
+		credentials: creds,
+	}
+}
+
+// Wait is a request handler which blocks until the request is allowed by the rate limiter of the account.
+func (l *accountRateLimiter) Wait(r *request.Request) {
+	limiter, err := l.accountLimiter(r)
+	if err != nil {
+		r.Error = err
+		return
+	}
+
+	start := time.Now()
+	err = limiter.Wait(r.Context())
+	awsmetrics.CaptureRateLimiterWait(r.ClientInfo.ServiceName, time.Since(start))
+	if err != nil {
+		r.Error = errors.Wrap(err, "failed waiting for route53 rate limiter")
+	}
+}
+
+func (l *accountRateLimiter) accountLimiter(r *request.Request) (*rate.Limiter, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.limiter != nil {
+		return l.limiter, nil
+	}
+
+	client := sts.New(l.session, &aws.Config{Credentials: l.credentials})
+	out, err := client.GetCallerIdentityWithContext(r.Context(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up aws account for route53 rate limiter")
+	}
+
+	limiter, _ := route53Limiters.LoadOrStore(aws.StringValue(out.Account), rate.NewLimiter(route53RateLimit, route53RateBurst))
+	l.limiter = limiter.(*rate.Limiter)
+	return l.limiter, nil
+}
@@ -2,11 +2,23 @@ package scope
 
 import (
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+
+	awsmetrics "github.com/giantswarm/dns-operator-aws/pkg/cloud/metrics"
 )
 
+// credentialsExpiryWindow makes cached assumed role credentials refresh before they expire,
+// so requests never use credentials expiring in flight.
+const credentialsExpiryWindow = time.Minute
+
 // ServiceEndpoint defines a tuple containing AWS Service resolution information
 type ServiceEndpoint struct {
 	ServiceID     string
@@ -20,6 +32,26 @@ type sessionCacheEntry struct {
 	session *session.Session
 }
 
+// credentialsCache holds the credentials of the identities used per session, so assumed roles
+// are only assumed again once their credentials expire. Credentials which aren't used anymore are
+// removed after cacheIdleTimeout.
+var credentialsCache idleCache
+
+type credentialsCacheKey struct {
+	session awsclient.ConfigProvider
+	// identity identifies the credentials, e.g. the role ARN and external ID
+	identity string
+}
+
+// clientCache holds the AWS clients per session and credentials. Clients of credentials which aren't
+// used anymore are removed after cacheIdleTimeout.
+var clientCache idleCache
+
+type clientCacheKey struct {
+	session     awsclient.ConfigProvider
+	credentials *credentials.Credentials
+}
+
 func sessionForRegion(region string) (*session.Session, error) {
 	if s, ok := sessionCache.Load(region); ok {
 		entry := s.(*sessionCacheEntry)
@@ -38,3 +70,41 @@ func sessionForRegion(region string) (*session.Session, error) {
 	})
 	return ns, nil
 }
+
+// cachedCredentials returns the cached credentials of the identity, new credentials are created and
+// cached on the first use.
+func cachedCredentials(session awsclient.ConfigProvider, identity string, create func() *credentials.Credentials) *credentials.Credentials {
+	key := credentialsCacheKey{session: session, identity: identity}
+	if c, ok := credentialsCache.load(key); ok {
+		awsmetrics.CaptureCacheRequest(awsmetrics.CacheCredentials, true)
+		return c.(*credentials.Credentials)
+	}
+	awsmetrics.CaptureCacheRequest(awsmetrics.CacheCredentials, false)
+
+	return credentialsCache.loadOrStore(key, create()).(*credentials.Credentials)
+}
+
+// cachedClients returns the cached clients for the credentials, nil credentials are the credentials of the session.
+func cachedClients(session awsclient.ConfigProvider, creds *credentials.Credentials) *AWSClients {
+	key := clientCacheKey{session: session, credentials: creds}
+	if c, ok := clientCache.load(key); ok {
+		awsmetrics.CaptureCacheRequest(awsmetrics.CacheClients, true)
+		return c.(*AWSClients)
+	}
+	awsmetrics.CaptureCacheRequest(awsmetrics.CacheClients, false)
+
+	clients := &AWSClients{
+		Route53:         route53.New(session, &aws.Config{Credentials: creds}),
+		Route53Resolver: route53resolver.New(session, &aws.Config{Credentials: creds}),
+	}
+	for _, handlers := range []*request.Handlers{&clients.Route53.Handlers, &clients.Route53Resolver.Handlers} {
+		handlers.Build.PushFrontNamed(getUserAgentHandler())
+		handlers.Build.PushFrontNamed(getOperationTimeoutHandler())
+		handlers.Build.PushFrontNamed(getTracingHandler())
+		handlers.CompleteAttempt.PushFront(awsmetrics.CaptureRequestMetrics("dns-operator-aws"))
+	}
+	// every attempt counts against the account wide Route53 limit, including retries
+	clients.Route53.Handlers.Send.PushFront(newAccountRateLimiter(session, creds).Wait)
+
+	return clientCache.loadOrStore(key, clients).(*AWSClients)
+}
@@ -0,0 +1,70 @@
+package scope
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/pkg/errors"
+)
+
+// DefaultAWSRequestTimeout bounds a single AWS operation including its retries.
+const DefaultAWSRequestTimeout = 30 * time.Second
+
+var (
+	awsRequestTimeout    = DefaultAWSRequestTimeout
+	awsOperationTimeouts = map[string]time.Duration{}
+)
+
+// SetAWSRequestTimeouts configures the timeout of AWS operations. operationTimeouts overrides the
+// timeout per operation name, e.g. `CreateHostedZone`. A timeout of 0 disables the timeout.
+// It has to be called before the first client is created.
+func SetAWSRequestTimeouts(timeout time.Duration, operationTimeouts map[string]time.Duration) {
+	awsRequestTimeout = timeout
+	awsOperationTimeouts = operationTimeouts
+}
+
+// ParseOperationTimeouts parses comma separated `<operation>=<duration>` pairs,
+// e.g. `CreateHostedZone=1m,ListResourceRecordSets=45s`.
+func ParseOperationTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	if value == "" {
+		return timeouts, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		operation, duration, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || operation == "" {
+			return nil, errors.Errorf("invalid operation timeout %q, expected <operation>=<duration>", pair)
+		}
+		timeout, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid timeout of operation %s", operation)
+		}
+		timeouts[operation] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// getOperationTimeoutHandler bounds the context of every request by the timeout of its operation.
+// It runs once per request, so the timeout includes retries and waiting for the rate limiter.
+func getOperationTimeoutHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "dns-operator-aws/operation-timeout",
+		Fn: func(r *request.Request) {
+			timeout, ok := awsOperationTimeouts[r.Operation.Name]
+			if !ok {
+				timeout = awsRequestTimeout
+			}
+			if timeout <= 0 {
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(*request.Request) { cancel() })
+		},
+	}
+}
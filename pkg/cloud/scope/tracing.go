@@ -0,0 +1,34 @@
+package scope
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/tracing"
+)
+
+// getTracingHandler starts a span per AWS operation, which ends when the request completes. It runs
+// once per request, so the span includes retries and waiting for the rate limiter.
+func getTracingHandler() request.NamedHandler {
+	return request.NamedHandler{
+		Name: "dns-operator-aws/tracing",
+		Fn: func(r *request.Request) {
+			ctx, span := tracing.StartSpan(r.Context(), r.ClientInfo.ServiceName+"."+r.Operation.Name,
+				semconv.RPCSystemKey.String("aws-api"),
+				semconv.RPCServiceKey.String(r.ClientInfo.ServiceName),
+				semconv.RPCMethodKey.String(r.Operation.Name),
+				attribute.String("aws.region", aws.StringValue(r.Config.Region)),
+			)
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(r *request.Request) {
+				span.SetAttributes(attribute.String("aws.request_id", r.RequestID), attribute.Int("aws.retry_count", r.RetryCount))
+				if r.HTTPResponse != nil {
+					span.SetAttributes(semconv.HTTPStatusCodeKey.Int(r.HTTPResponse.StatusCode))
+				}
+				tracing.EndSpan(span, r.Error)
+			})
+		},
+	}
+}
@@ -1,68 +1,179 @@
 package scope
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
-	gsannotations "github.com/giantswarm/k8smetadata/pkg/annotation"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	infrav1 "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
+	ekscontrolplanev1 "sigs.k8s.io/cluster-api-provider-aws/controlplane/eks/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	"github.com/giantswarm/dns-operator-aws/pkg/cloud"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+	"github.com/giantswarm/dns-operator-aws/pkg/records"
 )
 
 // ClusterScopeParams defines the input parameters used to create a new Scope.
 type ClusterScopeParams struct {
-	ARN                         string
 	AssociateResolverRules      bool
 	AWSCluster                  *infrav1.AWSCluster
+	AWSManagedControlPlane      *ekscontrolplanev1.AWSManagedControlPlane
 	BaseDomain                  string
 	BastionIP                   string
+	BastionIPv6                 string
+	ClusterName                 string
+	DelegationSetID             string
+	DelegationSetMode           string
+	DNSSEC                      bool
+	DNSSECKMSKeyARN             string
+	IdentityResolver            *IdentityResolver
 	Logger                      logr.Logger
+	ManagementClusterName       string
+	QueryLoggingLogGroupARN     string
+	RecordTemplates             *records.Templates
+	ResolverQueryLogDestination string
 	Session                     awsclient.ConfigProvider
 	ResolverRulesOwnerAccountId string
 }
 
 // NewClusterScope creates a new Scope from the supplied parameters.
+// The workload cluster is described either by an AWSCluster or, for EKS clusters, by an AWSManagedControlPlane.
 // This is meant to be called for each reconcile iteration.
-func NewClusterScope(params ClusterScopeParams) (*ClusterScope, error) {
-	if params.ARN == "" {
-		return nil, errors.New("failed to generate new scope from emtpy string ARN")
-	}
-	if params.AWSCluster == nil {
-		return nil, errors.New("failed to generate new scope from nil AWSCluster")
+func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterScope, error) {
+	if params.IdentityResolver == nil {
+		return nil, errors.New("failed to generate new scope from nil IdentityResolver")
 	}
 	if params.BaseDomain == "" {
 		return nil, errors.New("failed to generate new scope from emtpy string BaseDomain")
 	}
 
-	var additionalVPCToAssign []string
-	privateZone := false
-	annotation, ok := params.AWSCluster.Annotations[gsannotations.AWSDNSMode]
-	if ok && annotation == gsannotations.DNSModePrivate {
-		privateZone = true
+	var (
+		infraCluster   cloud.ClusterObject
+		identityRef    *infrav1.AWSIdentityReference
+		name           string
+		region         string
+		network        infrav1.NetworkSpec
+		apiEndpoint    capi.APIEndpoint
+		apiRecordCNAME bool
+	)
+	switch {
+	case params.AWSCluster != nil:
+		infraCluster = params.AWSCluster
+		identityRef = params.AWSCluster.Spec.IdentityRef
+		name = params.AWSCluster.Name
+		region = params.AWSCluster.Spec.Region
+		network = params.AWSCluster.Spec.NetworkSpec
+		apiEndpoint = params.AWSCluster.Spec.ControlPlaneEndpoint
+	case params.AWSManagedControlPlane != nil:
+		// the AWSManagedControlPlane is usually not named like the cluster
+		if params.ClusterName == "" {
+			return nil, errors.New("failed to generate new scope from emtpy string ClusterName")
+		}
+		infraCluster = params.AWSManagedControlPlane
+		identityRef = params.AWSManagedControlPlane.Spec.IdentityRef
+		name = params.ClusterName
+		region = params.AWSManagedControlPlane.Spec.Region
+		network = params.AWSManagedControlPlane.Spec.NetworkSpec
+		apiEndpoint = params.AWSManagedControlPlane.Spec.ControlPlaneEndpoint
+		// the EKS API endpoint is an URL and not a load balancer, so it can't be the target of an alias record
+		if u, err := url.Parse(apiEndpoint.Host); err == nil && u.Host != "" {
+			apiEndpoint.Host = u.Hostname()
+		}
+		apiRecordCNAME = true
+	default:
+		return nil, errors.New("failed to generate new scope from nil AWSCluster and AWSManagedControlPlane")
+	}
+	annotations := infraCluster.GetAnnotations()
 
-		additionalVPCList, ok := params.AWSCluster.Annotations[gsannotations.AWSDNSAdditionalVPC]
-		if ok {
-			additionalVPCToAssign = strings.Split(additionalVPCList, ",")
+	// deleted clusters are cleaned up with the valid annotations, the values the operator accepted before
+	// they were validated must not block the removal of the finalizer
+	clusterAnnotations, errs := ParseClusterAnnotations(annotations)
+	if len(errs) > 0 {
+		if infraCluster.GetDeletionTimestamp().IsZero() {
+			return nil, &InvalidAnnotationsError{errs: errs}
 		}
+		params.Logger.Info("Ignoring invalid annotations of deleted cluster", "errors", errs.ToAggregate().Error())
+	}
+	privateZone := clusterAnnotations.PrivateZone
+
+	// DNSSEC is only supported for public zones, the cluster annotation takes precedence over the operator configuration
+	dnssec := params.DNSSEC
+	if clusterAnnotations.DNSSEC != nil {
+		dnssec = *clusterAnnotations.DNSSEC
+	}
+	dnssec = dnssec && !privateZone
+	// the key is only needed to sign the zone, so clusters can still be deleted without it
+	if dnssec && params.DNSSECKMSKeyARN == "" && infraCluster.GetDeletionTimestamp().IsZero() {
+		return nil, errors.New("failed to generate new scope with DNSSEC enabled from empty string DNSSECKMSKeyARN")
 	}
 
-	session, err := sessionForRegion(params.AWSCluster.Spec.Region)
+	// delegation set referenced by the cluster takes precedence over the operator configuration
+	delegationSetID := params.DelegationSetID
+	if clusterAnnotations.DelegationSetID != "" {
+		delegationSetID = clusterAnnotations.DelegationSetID
+	}
+
+	var delegationSetReference string
+	switch params.DelegationSetMode {
+	case key.DelegationSetModeInstallation:
+		delegationSetReference = fmt.Sprintf("dns-operator-aws/%s/installation", params.ManagementClusterName)
+	case key.DelegationSetModeCluster:
+		delegationSetReference = fmt.Sprintf("dns-operator-aws/%s/cluster/%s/%s", params.ManagementClusterName, infraCluster.GetNamespace(), name)
+	case "":
+	default:
+		return nil, errors.Errorf("failed to generate new scope from unknown delegation set mode %q", params.DelegationSetMode)
+	}
+
+	recordTemplates := params.RecordTemplates
+	if recordTemplates == nil {
+		recordTemplates = records.DefaultTemplates()
+	}
+
+	session, err := sessionForRegion(region)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create aws session")
 	}
 
+	creds, err := params.IdentityResolver.Credentials(ctx, session, identityRef, infraCluster.GetNamespace())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve aws identity")
+	}
+
 	return &ClusterScope{
-		assumeRole:                  params.ARN,
+		apiEndpoint:                 apiEndpoint,
+		apiHealthCheck:              clusterAnnotations.APIHealthCheck,
+		apiRecordCNAME:              apiRecordCNAME,
+		apiRoutingPolicy:            clusterAnnotations.APIRoutingPolicy,
 		associateResolverRules:      params.AssociateResolverRules,
-		additionalVPCtoAssign:       additionalVPCToAssign,
-		AWSCluster:                  params.AWSCluster,
+		additionalVPCtoAssign:       clusterAnnotations.AdditionalVPCs,
+		annotations:                 annotations,
 		baseDomain:                  params.BaseDomain,
 		bastionIP:                   params.BastionIP,
+		bastionIPv6:                 params.BastionIPv6,
+		credentials:                 creds,
+		delegationSetID:             delegationSetID,
+		delegationSetReference:      delegationSetReference,
+		dnssec:                      dnssec,
+		dnssecKMSKeyARN:             params.DNSSECKMSKeyARN,
+		dnsModeMigration:            clusterAnnotations.DNSModeMigration,
+		dualStack:                   clusterAnnotations.DualStack,
+		infraCluster:                infraCluster,
 		logger:                      params.Logger,
+		name:                        name,
+		network:                     network,
 		privateZone:                 privateZone,
+		queryLoggingLogGroupARN:     params.QueryLoggingLogGroupARN,
+		recordTemplates:             recordTemplates,
+		region:                      region,
+		resolverQueryLogDestination: params.ResolverQueryLogDestination,
 		session:                     session,
 		resolverRulesOwnerAccountId: params.ResolverRulesOwnerAccountId,
 	}, nil
@@ -70,14 +181,32 @@ func NewClusterScope(params ClusterScopeParams) (*ClusterScope, error) {
 
 // ClusterScope defines the basic context for an actuator to operate upon.
 type ClusterScope struct {
-	assumeRole                  string
+	apiEndpoint                 capi.APIEndpoint
+	apiHealthCheck              bool
+	apiRecordCNAME              bool
+	apiRoutingPolicy            string
 	associateResolverRules      bool
 	additionalVPCtoAssign       []string
-	AWSCluster                  *infrav1.AWSCluster
+	annotations                 map[string]string
 	baseDomain                  string
 	bastionIP                   string
+	bastionIPv6                 string
+	credentials                 *credentials.Credentials
+	delegationSetID             string
+	delegationSetReference      string
+	dnssec                      bool
+	dnssecKMSKeyARN             string
+	dnsModeMigration            bool
+	dualStack                   bool
+	infraCluster                cloud.ClusterObject
 	logger                      logr.Logger
+	name                        string
+	network                     infrav1.NetworkSpec
 	privateZone                 bool
+	queryLoggingLogGroupARN     string
+	recordTemplates             *records.Templates
+	region                      string
+	resolverQueryLogDestination string
 	session                     awsclient.ConfigProvider
 	resolverRulesOwnerAccountId string
 }
@@ -86,9 +215,10 @@ func (s *ClusterScope) Logger() logr.Logger {
 	return s.logger
 }
 
-// ARN returns the AWS SDK assumed role. Used for creating workload cluster client.
-func (s *ClusterScope) ARN() string {
-	return s.assumeRole
+// Credentials returns the AWS credentials of the workload cluster identity. Used for creating workload cluster client.
+// Nil credentials mean the controller's own credentials are used.
+func (s *ClusterScope) Credentials() *credentials.Credentials {
+	return s.credentials
 }
 
 // AssociateResolverRules enables assigning all resolver rules to workload cluster VPC
@@ -98,7 +228,27 @@ func (s *ClusterScope) AssociateResolverRules() bool {
 
 // APIEndpoint returns the AWS infrastructure Kubernetes API endpoint.
 func (s *ClusterScope) APIEndpoint() string {
-	return s.AWSCluster.Spec.ControlPlaneEndpoint.Host
+	return s.apiEndpoint.Host
+}
+
+// APIEndpointPort returns the port of the Kubernetes API endpoint.
+func (s *ClusterScope) APIEndpointPort() int32 {
+	return s.apiEndpoint.Port
+}
+
+// APIHealthCheck returns true if Route53 health checks should be created for the routed API records
+func (s *ClusterScope) APIHealthCheck() bool {
+	return s.apiHealthCheck
+}
+
+// APIRecordCNAME returns true if the `api` record is a CNAME instead of an alias to the control plane load balancer
+func (s *ClusterScope) APIRecordCNAME() bool {
+	return s.apiRecordCNAME
+}
+
+// APIRoutingPolicy returns the routing policy between the primary and secondary API endpoint
+func (s *ClusterScope) APIRoutingPolicy() string {
+	return s.apiRoutingPolicy
 }
 
 // BaseDomain returns the workload cluster basedomain.
@@ -110,14 +260,134 @@ func (s *ClusterScope) BastionIP() string {
 	return s.bastionIP
 }
 
+// BastionIPv6 returns the IPv6 address of the bastion machine, empty if the cluster is not dual-stack.
+func (s *ClusterScope) BastionIPv6() string {
+	if !s.dualStack {
+		return ""
+	}
+	return s.bastionIPv6
+}
+
+// DelegationSetID returns the ID of an existing reusable delegation set which should be used for the hosted zone.
+func (s *ClusterScope) DelegationSetID() string {
+	return s.delegationSetID
+}
+
+// DelegationSetReference returns the caller reference of the reusable delegation set managed by the operator.
+func (s *ClusterScope) DelegationSetReference() string {
+	return s.delegationSetReference
+}
+
+// DNSSEC returns true if the public route53 Zone should be signed with DNSSEC
+func (s *ClusterScope) DNSSEC() bool {
+	return s.dnssec
+}
+
+// DNSSECKMSKeyARN returns the ARN of the KMS key backing the DNSSEC key signing key
+func (s *ClusterScope) DNSSECKMSKeyARN() string {
+	return s.dnssecKMSKeyARN
+}
+
+// DNSModeMigration returns true if the zone of the previous DNS mode should be replaced by a zone of the current mode
+func (s *ClusterScope) DNSModeMigration() bool {
+	return s.dnsModeMigration
+}
+
+// DualStack returns true if `AAAA` records should be created for the dual-stack load balancers and bastion
+func (s *ClusterScope) DualStack() bool {
+	return s.dualStack
+}
+
+// HostedZoneID returns the workload cluster hosted zone ID persisted on the infrastructure cluster.
+func (s *ClusterScope) HostedZoneID() string {
+	return s.annotations[key.HostedZoneIDAnnotation]
+}
+
+// SetHostedZoneID persists the workload cluster hosted zone ID in an annotation of the infrastructure
+// cluster, an empty ID removes the annotation. The infrastructure cluster has to be patched afterwards.
+func (s *ClusterScope) SetHostedZoneID(id string) {
+	s.setAnnotation(key.HostedZoneIDAnnotation, id)
+}
+
+// ManagedRecords returns the records created from the record templates as `<type> <name>`, nil if
+// the operator didn't record them yet.
+func (s *ClusterScope) ManagedRecords() []string {
+	value := s.annotations[key.ManagedRecordsAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// SetManagedRecords persists the records created from the record templates in an annotation of the
+// infrastructure cluster. The infrastructure cluster has to be patched afterwards.
+func (s *ClusterScope) SetManagedRecords(records []string) {
+	sorted := append([]string(nil), records...)
+	sort.Strings(sorted)
+	s.setAnnotation(key.ManagedRecordsAnnotation, strings.Join(sorted, ","))
+}
+
+// ZoneFirstReady returns true if the workload cluster zone was ready before.
+func (s *ClusterScope) ZoneFirstReady() bool {
+	_, ok := s.annotations[key.ZoneFirstReadyAnnotation]
+	return ok
+}
+
+// SetZoneFirstReady persists the time the workload cluster zone became ready for the first time in an
+// annotation of the infrastructure cluster. The infrastructure cluster has to be patched afterwards.
+func (s *ClusterScope) SetZoneFirstReady(t time.Time) {
+	s.setAnnotation(key.ZoneFirstReadyAnnotation, t.UTC().Format(time.RFC3339))
+}
+
+// DNSSECDisableAfter returns the time after which DNSSEC signing of the workload cluster zone can be
+// disabled, false if the DS record wasn't removed from the parent zone by the operator.
+func (s *ClusterScope) DNSSECDisableAfter() (time.Time, bool) {
+	value, ok := s.annotations[key.DNSSECDisableAfterAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// wait like for a just removed DS record rather than breaking resolution of the zone
+		return time.Now().Add(time.Duration(s.recordTemplates.DelegationTTL()) * time.Second), true
+	}
+	return t, true
+}
+
+// SetDNSSECDisableAfter persists the time after which DNSSEC signing can be disabled in an annotation of
+// the infrastructure cluster, the zero time removes the annotation. The infrastructure cluster has to be
+// patched afterwards.
+func (s *ClusterScope) SetDNSSECDisableAfter(t time.Time) {
+	if t.IsZero() {
+		s.setAnnotation(key.DNSSECDisableAfterAnnotation, "")
+		return
+	}
+	s.setAnnotation(key.DNSSECDisableAfterAnnotation, t.UTC().Format(time.RFC3339))
+}
+
+// setAnnotation sets the annotation of the infrastructure cluster, an empty value removes the annotation.
+func (s *ClusterScope) setAnnotation(name, value string) {
+	annotations := s.infraCluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if value == "" {
+		delete(annotations, name)
+	} else {
+		annotations[name] = value
+	}
+	s.infraCluster.SetAnnotations(annotations)
+	s.annotations = annotations
+}
+
 // InfraCluster returns the AWS infrastructure cluster or control plane object.
 func (s *ClusterScope) InfraCluster() cloud.ClusterObject {
-	return s.AWSCluster
+	return s.infraCluster
 }
 
-// Name returns the AWS infrastructure cluster name.
+// Name returns the workload cluster name.
 func (s *ClusterScope) Name() string {
-	return s.AWSCluster.Name
+	return s.name
 }
 
 // PrivateZone returns true if the desired route53 Zone should be private
@@ -125,9 +395,24 @@ func (s *ClusterScope) PrivateZone() bool {
 	return s.privateZone
 }
 
+// QueryLoggingLogGroupARN returns the CloudWatch Logs log group ARN for query logging of public zones
+func (s *ClusterScope) QueryLoggingLogGroupARN() string {
+	return s.queryLoggingLogGroupARN
+}
+
+// RecordTemplates returns the templates of the records created for the workload cluster
+func (s *ClusterScope) RecordTemplates() *records.Templates {
+	return s.recordTemplates
+}
+
 // Region returns the cluster region.
 func (s *ClusterScope) Region() string {
-	return s.AWSCluster.Spec.Region
+	return s.region
+}
+
+// SecondaryAPIEndpoint returns the secondary Kubernetes LoadBalancer API endpoint
+func (s *ClusterScope) SecondaryAPIEndpoint() string {
+	return s.annotations[key.APISecondaryEndpointAnnotation]
 }
 
 // Session returns the AWS SDK session. Used for creating workload cluster client.
@@ -135,9 +420,9 @@ func (s *ClusterScope) Session() awsclient.ConfigProvider {
 	return s.session
 }
 
-// VPC returns the AWSCluster vpc ID
+// VPC returns the workload cluster vpc ID
 func (s *ClusterScope) VPC() string {
-	return s.AWSCluster.Spec.NetworkSpec.VPC.ID
+	return s.network.VPC.ID
 }
 
 // AdditionalVPCToAssign returns the list of extra VPC ids which should be assigned to a private hosted zone
@@ -145,6 +430,11 @@ func (s *ClusterScope) AdditionalVPCToAssign() []string {
 	return s.additionalVPCtoAssign
 }
 
+// ResolverQueryLogDestination returns the destination ARN for resolver query logging of private zones
+func (s *ClusterScope) ResolverQueryLogDestination() string {
+	return s.resolverQueryLogDestination
+}
+
 // ResolverRulesCreatorAccount returns the account id to be used to filter dns rules associations
 func (s *ClusterScope) ResolverRulesCreatorAccount() string {
 	return s.resolverRulesOwnerAccountId
@@ -152,5 +442,5 @@ func (s *ClusterScope) ResolverRulesCreatorAccount() string {
 
 // VPCCidr returns cidr of cluster's VPC
 func (s *ClusterScope) VPCCidr() string {
-	return s.AWSCluster.Spec.NetworkSpec.VPC.CidrBlock
+	return s.network.VPC.CidrBlock
 }
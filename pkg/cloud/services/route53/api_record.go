@@ -0,0 +1,368 @@
+package route53
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+)
+
+const (
+	apiSetIdentifierPrimary   = "primary"
+	apiSetIdentifierSecondary = "secondary"
+
+	apiHealthCheckPath             = "/healthz"
+	apiHealthCheckFailureThreshold = 3
+	apiHealthCheckRequestInterval  = 30
+)
+
+// reconcileAPIRecords keeps the `api` record in sync with the control plane endpoints of the cluster.
+// With a routing policy, one record per endpoint is created with optional Route53 health checks.
+// Dual-stack clusters get an `AAAA` record next to each `A` record, sharing the same health check.
+// EKS clusters get a `CNAME` record to the EKS API endpoint instead.
+// Changes are submitted in a single batch, so switching between a simple record and routed records
+// never leaves the `api` name unresolvable.
+func (s *Service) reconcileAPIRecords(ctx context.Context, hostZoneID string) error {
+	current, err := s.listAPIRecordSets(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+
+	currentByKey := map[string]*route53.ResourceRecordSet{}
+	for _, r := range current {
+		currentByKey[apiRecordSetKey(r)] = r
+	}
+
+	desired, createdHealthChecks, err := s.desiredAPIRecordSets(ctx, current)
+	if err != nil {
+		return s.cleanupHealthChecks(ctx, createdHealthChecks, err)
+	}
+
+	desiredByKey := map[string]bool{}
+	for _, r := range desired {
+		desiredByKey[apiRecordSetKey(r)] = true
+	}
+
+	// deletions go first, a `CNAME` record can't coexist with other records of the same name
+	var changes []*route53.Change
+	for _, r := range current {
+		if !desiredByKey[apiRecordSetKey(r)] {
+			changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: r})
+		}
+	}
+	for _, r := range desired {
+		if c, ok := currentByKey[apiRecordSetKey(r)]; ok && equalAPIRecordSets(c, r) {
+			continue
+		}
+		changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionUpsert), ResourceRecordSet: r})
+	}
+
+	if len(changes) > 0 {
+		input := &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(hostZoneID),
+			ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+		}
+		out, err := s.Route53Client.ChangeResourceRecordSetsWithContext(ctx, input)
+		if err != nil {
+			// health checks created for the records are not referenced by any record
+			return s.cleanupHealthChecks(ctx, createdHealthChecks, errors.Wrap(err, "failed to change API DNS records"))
+		}
+		s.logger().Info("Changed API DNS records", "changes", len(changes), "changeID", aws.StringValue(out.ChangeInfo.Id))
+		s.countRecordChanges(changes, current)
+		s.recordChangesEvent(changes, current)
+	}
+
+	// health checks which are not referenced anymore can be removed once the records are updated
+	var obsolete []string
+	for _, c := range current {
+		if c.HealthCheckId == nil {
+			continue
+		}
+		used := false
+		for _, d := range desired {
+			if aws.StringValue(d.HealthCheckId) == aws.StringValue(c.HealthCheckId) {
+				used = true
+			}
+		}
+		if !used {
+			obsolete = append(obsolete, aws.StringValue(c.HealthCheckId))
+		}
+	}
+
+	return s.deleteHealthChecks(ctx, obsolete)
+}
+
+// desiredAPIRecordSets returns the `api` record sets for the configured routing policy and IP families
+// and the IDs of the health checks created for them, also when an error is returned.
+func (s *Service) desiredAPIRecordSets(ctx context.Context, current []*route53.ResourceRecordSet) ([]*route53.ResourceRecordSet, []string, error) {
+	name, err := s.scope.RecordTemplates().APIName(s.recordTemplateData())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.scope.APIRecordCNAME() {
+		return []*route53.ResourceRecordSet{
+			{
+				Name:            aws.String(name),
+				Type:            aws.String(route53.RRTypeCname),
+				TTL:             aws.Int64(s.scope.RecordTemplates().APITTL()),
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(s.scope.APIEndpoint())}},
+			},
+		}, nil, nil
+	}
+
+	recordTypes := []string{route53.RRTypeA}
+	if s.scope.DualStack() {
+		recordTypes = append(recordTypes, route53.RRTypeAaaa)
+	}
+
+	var recordSets []*route53.ResourceRecordSet
+	if s.scope.APIRoutingPolicy() == "" || s.scope.SecondaryAPIEndpoint() == "" {
+		for _, recordType := range recordTypes {
+			recordSets = append(recordSets, &route53.ResourceRecordSet{
+				Name: aws.String(name),
+				Type: aws.String(recordType),
+				AliasTarget: &route53.AliasTarget{
+					DNSName:              aws.String(s.scope.APIEndpoint()),
+					EvaluateTargetHealth: aws.Bool(false),
+					HostedZoneId:         aws.String(canonicalHostedZones[s.scope.Region()]),
+				},
+			})
+		}
+		return recordSets, nil, nil
+	}
+
+	endpoints := map[string]string{
+		apiSetIdentifierPrimary:   s.scope.APIEndpoint(),
+		apiSetIdentifierSecondary: s.scope.SecondaryAPIEndpoint(),
+	}
+
+	var createdHealthChecks []string
+	for _, setIdentifier := range []string{apiSetIdentifierPrimary, apiSetIdentifierSecondary} {
+		var healthCheckID *string
+		if s.scope.APIHealthCheck() {
+			// records of both IP families route to the same endpoint and share the health check
+			var currentRecordSet *route53.ResourceRecordSet
+			for _, c := range current {
+				if aws.StringValue(c.SetIdentifier) == setIdentifier && c.HealthCheckId != nil {
+					currentRecordSet = c
+					break
+				}
+			}
+			id, created, err := s.reconcileHealthCheck(ctx, currentRecordSet, setIdentifier, endpoints[setIdentifier])
+			if created {
+				createdHealthChecks = append(createdHealthChecks, id)
+			}
+			if err != nil {
+				return nil, createdHealthChecks, err
+			}
+			healthCheckID = aws.String(id)
+		}
+
+		for _, recordType := range recordTypes {
+			r := &route53.ResourceRecordSet{
+				Name:          aws.String(name),
+				Type:          aws.String(recordType),
+				SetIdentifier: aws.String(setIdentifier),
+				AliasTarget: &route53.AliasTarget{
+					DNSName:              aws.String(endpoints[setIdentifier]),
+					EvaluateTargetHealth: aws.Bool(true),
+					HostedZoneId:         aws.String(canonicalHostedZones[s.scope.Region()]),
+				},
+				HealthCheckId: healthCheckID,
+			}
+
+			switch s.scope.APIRoutingPolicy() {
+			case key.APIRoutingPolicyFailover:
+				r.Failover = aws.String(strings.ToUpper(setIdentifier))
+			case key.APIRoutingPolicyWeighted:
+				r.Weight = aws.Int64(1)
+			}
+
+			recordSets = append(recordSets, r)
+		}
+	}
+
+	return recordSets, createdHealthChecks, nil
+}
+
+// reconcileHealthCheck returns the ID of the health check of the current record if it checks the given
+// endpoint, otherwise a health check is created. created is true if the returned health check isn't
+// referenced by the current record, it is also returned along with errors tagging the health check.
+func (s *Service) reconcileHealthCheck(ctx context.Context, current *route53.ResourceRecordSet, setIdentifier, endpoint string) (id string, created bool, err error) {
+	if current != nil && current.HealthCheckId != nil {
+		out, err := s.Route53Client.GetHealthCheckWithContext(ctx, &route53.GetHealthCheckInput{HealthCheckId: current.HealthCheckId})
+		if code, ok := awserrors.Code(errors.Cause(err)); ok && code == route53.ErrCodeNoSuchHealthCheck {
+			// fall through
+		} else if err != nil {
+			return "", false, err
+		} else if config := out.HealthCheck.HealthCheckConfig; config != nil &&
+			aws.StringValue(config.FullyQualifiedDomainName) == endpoint &&
+			aws.Int64Value(config.Port) == s.apiHealthCheckPort() {
+			return aws.StringValue(current.HealthCheckId), false, nil
+		}
+	}
+
+	// Route53 returns the existing health check for a caller reference it has seen with the same
+	// settings, so health checks created by reconciles which failed before referencing them are reused
+	input := &route53.CreateHealthCheckInput{
+		CallerReference: aws.String(s.healthCheckCallerReference(setIdentifier, endpoint)),
+		HealthCheckConfig: &route53.HealthCheckConfig{
+			FailureThreshold:         aws.Int64(apiHealthCheckFailureThreshold),
+			FullyQualifiedDomainName: aws.String(endpoint),
+			Port:                     aws.Int64(s.apiHealthCheckPort()),
+			RequestInterval:          aws.Int64(apiHealthCheckRequestInterval),
+			ResourcePath:             aws.String(apiHealthCheckPath),
+			Type:                     aws.String(route53.HealthCheckTypeHttps),
+		},
+	}
+	out, err := s.Route53Client.CreateHealthCheckWithContext(ctx, input)
+	if code, ok := awserrors.Code(errors.Cause(err)); ok && code == route53.ErrCodeHealthCheckAlreadyExists {
+		// caller references of deleted health checks can't be reused, e.g. when an endpoint is used again
+		input.CallerReference = aws.String(fmt.Sprintf("%s-%d", aws.StringValue(input.CallerReference), time.Now().UnixNano()))
+		out, err = s.Route53Client.CreateHealthCheckWithContext(ctx, input)
+	}
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to create %s API health check for cluster %s", setIdentifier, s.scope.Name())
+	}
+	id = aws.StringValue(out.HealthCheck.Id)
+
+	// tag health check, so it can be related to the cluster
+	tagsInput := &route53.ChangeTagsForResourceInput{
+		AddTags: []*route53.Tag{
+			{
+				Key:   aws.String("Name"),
+				Value: aws.String(fmt.Sprintf("%s-api-%s", s.scope.Name(), setIdentifier)),
+			},
+			{
+				Key:   aws.String(fmt.Sprintf("sigs.k8s.io/cluster-api-provider-aws/cluster/%s", s.scope.Name())),
+				Value: aws.String("owned"),
+			},
+		},
+		ResourceId:   out.HealthCheck.Id,
+		ResourceType: aws.String(route53.TagResourceTypeHealthcheck),
+	}
+	_, err = s.Route53Client.ChangeTagsForResourceWithContext(ctx, tagsInput)
+	if err != nil {
+		return id, true, errors.Wrapf(err, "failed to add tags to API health check for cluster %s", s.scope.Name())
+	}
+	s.logger().Info("Created API health check", "setIdentifier", setIdentifier, "healthCheckID", id)
+
+	return id, true, nil
+}
+
+// healthCheckCallerReference returns the caller reference of the health check of the endpoint. It is
+// derived from the cluster, set identifier and endpoint, so retried reconciles don't create new health checks.
+func (s *Service) healthCheckCallerReference(setIdentifier, endpoint string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%d", s.scope.InfraCluster().GetUID(), setIdentifier, endpoint, s.apiHealthCheckPort())))
+	// caller references are limited to 64 characters
+	return fmt.Sprintf("%s-%x", setIdentifier, hash[:8])
+}
+
+// cleanupHealthChecks deletes the health checks created by a failed reconcile of the `api` records,
+// which are not referenced by any record, and returns the reconcile error.
+func (s *Service) cleanupHealthChecks(ctx context.Context, healthCheckIDs []string, reconcileErr error) error {
+	err := s.deleteHealthChecks(ctx, healthCheckIDs)
+	if err != nil {
+		s.logger().Error(err, "failed to delete unused API health checks")
+	}
+	return reconcileErr
+}
+
+// listAPIHealthChecks returns the IDs of the health checks referenced by the `api` records.
+func (s *Service) listAPIHealthChecks(ctx context.Context, hostZoneID string) ([]string, error) {
+	recordSets, err := s.listAPIRecordSets(ctx, hostZoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	var healthCheckIDs []string
+	seen := map[string]bool{}
+	for _, r := range recordSets {
+		if r.HealthCheckId != nil && !seen[aws.StringValue(r.HealthCheckId)] {
+			seen[aws.StringValue(r.HealthCheckId)] = true
+			healthCheckIDs = append(healthCheckIDs, aws.StringValue(r.HealthCheckId))
+		}
+	}
+	return healthCheckIDs, nil
+}
+
+func (s *Service) deleteHealthChecks(ctx context.Context, healthCheckIDs []string) error {
+	for _, id := range healthCheckIDs {
+		_, err := s.Route53Client.DeleteHealthCheckWithContext(ctx, &route53.DeleteHealthCheckInput{HealthCheckId: aws.String(id)})
+		if code, ok := awserrors.Code(errors.Cause(err)); ok && code == route53.ErrCodeNoSuchHealthCheck {
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to delete API health check %s for cluster %s", id, s.scope.Name())
+		}
+		s.logger().Info("Deleted API health check", "healthCheckID", id)
+	}
+	return nil
+}
+
+// listAPIRecordSets returns all `A`, `AAAA` and `CNAME` record sets of the `api` record name.
+func (s *Service) listAPIRecordSets(ctx context.Context, hostZoneID string) ([]*route53.ResourceRecordSet, error) {
+	name, err := s.scope.RecordTemplates().APIName(s.recordTemplateData())
+	if err != nil {
+		return nil, err
+	}
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostZoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(route53.RRTypeA),
+	}
+	out, err := s.Route53Client.ListResourceRecordSetsWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordSets []*route53.ResourceRecordSet
+	for _, r := range out.ResourceRecordSets {
+		if !strings.EqualFold(aws.StringValue(r.Name), name) {
+			break
+		}
+		if t := aws.StringValue(r.Type); t == route53.RRTypeA || t == route53.RRTypeAaaa || t == route53.RRTypeCname {
+			recordSets = append(recordSets, r)
+		}
+	}
+	return recordSets, nil
+}
+
+// apiRecordSetKey identifies an `api` record set by its type and set identifier.
+func apiRecordSetKey(r *route53.ResourceRecordSet) string {
+	return fmt.Sprintf("%s/%s", aws.StringValue(r.Type), aws.StringValue(r.SetIdentifier))
+}
+
+func (s *Service) apiHealthCheckPort() int64 {
+	if s.scope.APIEndpointPort() == 0 {
+		return 443
+	}
+	return int64(s.scope.APIEndpointPort())
+}
+
+// equalAPIRecordSets returns true if both record sets route the same way to the same target.
+func equalAPIRecordSets(a, b *route53.ResourceRecordSet) bool {
+	if a.AliasTarget == nil && b.AliasTarget == nil {
+		return aws.Int64Value(a.TTL) == aws.Int64Value(b.TTL) && equalResourceRecords(a.ResourceRecords, b.ResourceRecords)
+	}
+	if a.AliasTarget == nil || b.AliasTarget == nil {
+		return false
+	}
+
+	return strings.EqualFold(strings.TrimSuffix(aws.StringValue(a.AliasTarget.DNSName), "."), strings.TrimSuffix(aws.StringValue(b.AliasTarget.DNSName), ".")) &&
+		aws.StringValue(a.AliasTarget.HostedZoneId) == aws.StringValue(b.AliasTarget.HostedZoneId) &&
+		aws.BoolValue(a.AliasTarget.EvaluateTargetHealth) == aws.BoolValue(b.AliasTarget.EvaluateTargetHealth) &&
+		aws.StringValue(a.SetIdentifier) == aws.StringValue(b.SetIdentifier) &&
+		aws.StringValue(a.Failover) == aws.StringValue(b.Failover) &&
+		aws.Int64Value(a.Weight) == aws.Int64Value(b.Weight) &&
+		aws.StringValue(a.HealthCheckId) == aws.StringValue(b.HealthCheckId)
+}
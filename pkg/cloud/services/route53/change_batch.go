@@ -0,0 +1,189 @@
+package route53
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+)
+
+const (
+	// changeBatchWindow is the time changes of the same hosted zone are collected before they are submitted.
+	changeBatchWindow = 200 * time.Millisecond
+	// maxChangesPerBatch stays well below the Route53 limits of 1000 changes and 32000 characters per request.
+	maxChangesPerBatch = 100
+)
+
+// zoneChangeBatcher collects the record changes of all reconciles, so concurrent reconciles changing
+// the same hosted zone, i.e. the delegation records in the management cluster zone, share a request.
+var zoneChangeBatcher = &changeBatcher{
+	batches: map[string]*changeBatch{},
+}
+
+// batchingClient submits record changes through the zoneChangeBatcher, all other calls go to the
+// wrapped client.
+type batchingClient struct {
+	route53iface.Route53API
+}
+
+func newBatchingClient(client route53iface.Route53API) route53iface.Route53API {
+	return &batchingClient{Route53API: client}
+}
+
+func (c *batchingClient) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	return c.ChangeResourceRecordSetsWithContext(aws.BackgroundContext(), input)
+}
+
+func (c *batchingClient) ChangeResourceRecordSetsWithContext(ctx aws.Context, input *route53.ChangeResourceRecordSetsInput, opts ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	if input.ChangeBatch == nil || len(input.ChangeBatch.Changes) > maxChangesPerBatch || len(opts) > 0 {
+		return c.Route53API.ChangeResourceRecordSetsWithContext(ctx, input, opts...)
+	}
+	return zoneChangeBatcher.change(ctx, c.Route53API, input)
+}
+
+type changeBatcher struct {
+	mutex   sync.Mutex
+	batches map[string]*changeBatch
+}
+
+// changeBatch holds the pending change requests of a hosted zone.
+type changeBatch struct {
+	zoneID   string
+	requests []*changeRequest
+	changes  int
+	// records holds the changed records, a batch must not change a record twice
+	records map[string]bool
+	once    sync.Once
+}
+
+type changeRequest struct {
+	ctx    context.Context
+	client route53iface.Route53API
+	input  *route53.ChangeResourceRecordSetsInput
+	result chan changeResult
+}
+
+type changeResult struct {
+	output *route53.ChangeResourceRecordSetsOutput
+	err    error
+}
+
+// change adds the changes to the pending batch of the hosted zone and waits for the result of the batch,
+// also when the context is canceled.
+func (b *changeBatcher) change(ctx context.Context, client route53iface.Route53API, input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	req := &changeRequest{
+		ctx:    ctx,
+		client: client,
+		input:  input,
+		result: make(chan changeResult, 1),
+	}
+	zoneID := aws.StringValue(input.HostedZoneId)
+
+	b.mutex.Lock()
+	batch := b.batches[zoneID]
+	if batch != nil && !batch.fits(input.ChangeBatch.Changes) {
+		delete(b.batches, zoneID)
+		go batch.submit()
+		batch = nil
+	}
+	if batch == nil {
+		batch = &changeBatch{
+			zoneID:  zoneID,
+			records: map[string]bool{},
+		}
+		b.batches[zoneID] = batch
+		time.AfterFunc(changeBatchWindow, func() { b.flush(batch) })
+	}
+	batch.add(req)
+	b.mutex.Unlock()
+
+	// the changes may still be applied by the combined request after the context is canceled, so the
+	// caller waits for the result, which is bounded by the timeout of the request
+	result := <-req.result
+	return result.output, result.err
+}
+
+// flush submits the batch once its window is over, unless it was already submitted because it was full.
+func (b *changeBatcher) flush(batch *changeBatch) {
+	b.mutex.Lock()
+	if b.batches[batch.zoneID] == batch {
+		delete(b.batches, batch.zoneID)
+	}
+	b.mutex.Unlock()
+
+	batch.submit()
+}
+
+func (b *changeBatch) fits(changes []*route53.Change) bool {
+	if b.changes+len(changes) > maxChangesPerBatch {
+		return false
+	}
+	for _, change := range changes {
+		if b.records[changeRecordKey(change)] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *changeBatch) add(req *changeRequest) {
+	b.requests = append(b.requests, req)
+	b.changes += len(req.input.ChangeBatch.Changes)
+	for _, change := range req.input.ChangeBatch.Changes {
+		b.records[changeRecordKey(change)] = true
+	}
+}
+
+// submit sends all changes of the batch in a single request. When the combined request is rejected
+// because of the changes, the requests are submitted one by one, so every caller gets the error caused
+// by its own changes. Other errors, like throttling, are returned to all callers as resending the
+// requests would only add load. The combined request doesn't belong to a single caller, so it isn't
+// canceled with their contexts.
+func (b *changeBatch) submit() {
+	b.once.Do(func() {
+		if len(b.requests) == 1 {
+			b.requests[0].send()
+			return
+		}
+
+		var changes []*route53.Change
+		for _, req := range b.requests {
+			changes = append(changes, req.input.ChangeBatch.Changes...)
+		}
+		output, err := b.requests[0].client.ChangeResourceRecordSetsWithContext(context.Background(), &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(b.zoneID),
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: changes,
+			},
+		})
+		if code, ok := awserrors.Code(errors.Cause(err)); ok && code == route53.ErrCodeInvalidChangeBatch {
+			for _, req := range b.requests {
+				req.send()
+			}
+			return
+		}
+
+		for _, req := range b.requests {
+			req.result <- changeResult{output: output, err: err}
+		}
+	})
+}
+
+func (r *changeRequest) send() {
+	output, err := r.client.ChangeResourceRecordSetsWithContext(r.ctx, r.input)
+	r.result <- changeResult{output: output, err: err}
+}
+
+func changeRecordKey(change *route53.Change) string {
+	if change.ResourceRecordSet == nil {
+		return ""
+	}
+	return aws.StringValue(change.ResourceRecordSet.Name) + "/" + aws.StringValue(change.ResourceRecordSet.Type) + "/" + aws.StringValue(change.ResourceRecordSet.SetIdentifier)
+}
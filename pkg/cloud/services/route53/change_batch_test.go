@@ -0,0 +1,264 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+)
+
+// fakeChangeClient records the change requests and fails them with the error returned by fail.
+type fakeChangeClient struct {
+	route53iface.Route53API
+
+	mutex sync.Mutex
+	calls [][]*route53.Change
+	fail  func(changes []*route53.Change) error
+}
+
+func (c *fakeChangeClient) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	c.mutex.Lock()
+	c.calls = append(c.calls, input.ChangeBatch.Changes)
+	c.mutex.Unlock()
+
+	if c.fail != nil {
+		if err := c.fail(input.ChangeBatch.Changes); err != nil {
+			return nil, err
+		}
+	}
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("change")}}, nil
+}
+
+func (c *fakeChangeClient) callSizes() []int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var sizes []int
+	for _, changes := range c.calls {
+		sizes = append(sizes, len(changes))
+	}
+	return sizes
+}
+
+func newTestBatcher() *changeBatcher {
+	return &changeBatcher{batches: map[string]*changeBatch{}}
+}
+
+func changeInput(names ...string) *route53.ChangeResourceRecordSetsInput {
+	var changes []*route53.Change
+	for _, name := range names {
+		changes = append(changes, &route53.Change{
+			Action: aws.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name: aws.String(name),
+				Type: aws.String(route53.RRTypeNs),
+			},
+		})
+	}
+	return &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String("Z1"),
+		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+	}
+}
+
+func recordNames(prefix string, n int) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		names = append(names, fmt.Sprintf("%s-%d.example.com.", prefix, i))
+	}
+	return names
+}
+
+// changeConcurrently submits the inputs in order, each one once the previous one was added to a batch,
+// and returns the errors of all requests.
+func changeConcurrently(t *testing.T, b *changeBatcher, client route53iface.Route53API, inputs ...*route53.ChangeResourceRecordSetsInput) []error {
+	t.Helper()
+
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		added := b.pendingChanges("Z1")
+		wg.Add(1)
+		go func(i int, input *route53.ChangeResourceRecordSetsInput) {
+			defer wg.Done()
+			_, errs[i] = b.change(context.Background(), client, input)
+		}(i, input)
+
+		// wait until the request is added to a batch, so the order of the requests is known
+		deadline := time.Now().Add(changeBatchWindow / 2)
+		for b.pendingChanges("Z1") == added && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// pendingChanges returns the number of changes of the pending batch of the zone, -1 if there is none.
+func (b *changeBatcher) pendingChanges(zoneID string) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if batch := b.batches[zoneID]; batch != nil {
+		return batch.changes
+	}
+	return -1
+}
+
+func equalSizes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestChangeBatcherFlushesWindow(t *testing.T) {
+	b := newTestBatcher()
+	client := &fakeChangeClient{}
+
+	start := time.Now()
+	errs := changeConcurrently(t, b, client, changeInput("a.example.com."), changeInput("b.example.com.", "c.example.com."))
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if sizes := client.callSizes(); !equalSizes(sizes, []int{3}) {
+		t.Fatalf("expected a single request with 3 changes, got %v", sizes)
+	}
+	if elapsed := time.Since(start); elapsed < changeBatchWindow {
+		t.Fatalf("expected the batch to be submitted after the window, got %s", elapsed)
+	}
+}
+
+func TestChangeBatcherSplitsFullBatch(t *testing.T) {
+	b := newTestBatcher()
+	client := &fakeChangeClient{}
+
+	errs := changeConcurrently(t, b, client,
+		changeInput(recordNames("a", maxChangesPerBatch-10)...),
+		changeInput(recordNames("b", 20)...),
+	)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if sizes := client.callSizes(); !equalSizes(sizes, []int{maxChangesPerBatch - 10, 20}) {
+		t.Fatalf("expected the full batch to be submitted before the next one, got %v", sizes)
+	}
+}
+
+func TestChangeBatcherSeparatesDuplicateRecords(t *testing.T) {
+	b := newTestBatcher()
+	client := &fakeChangeClient{}
+
+	errs := changeConcurrently(t, b, client,
+		changeInput("a.example.com.", "b.example.com."),
+		changeInput("b.example.com."),
+	)
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if sizes := client.callSizes(); !equalSizes(sizes, []int{2, 1}) {
+		t.Fatalf("expected a record to be changed once per request, got %v", sizes)
+	}
+}
+
+func TestChangeBatcherFailure(t *testing.T) {
+	invalidRecord := "invalid.example.com."
+
+	testCases := []struct {
+		name      string
+		err       error
+		wantSizes []int
+		wantErrs  []bool
+	}{
+		{
+			name:      "invalid change batch is split into the requests",
+			err:       awserr.New(route53.ErrCodeInvalidChangeBatch, "invalid change", nil),
+			wantSizes: []int{2, 1, 1},
+			wantErrs:  []bool{false, true},
+		},
+		{
+			name:      "throttling is returned to all requests",
+			err:       awserr.New("Throttling", "rate exceeded", nil),
+			wantSizes: []int{2},
+			wantErrs:  []bool{true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newTestBatcher()
+			client := &fakeChangeClient{
+				fail: func(changes []*route53.Change) error {
+					for _, change := range changes {
+						if aws.StringValue(change.ResourceRecordSet.Name) == invalidRecord {
+							return tc.err
+						}
+					}
+					return nil
+				},
+			}
+
+			errs := changeConcurrently(t, b, client, changeInput("a.example.com."), changeInput(invalidRecord))
+
+			if sizes := client.callSizes(); !equalSizes(sizes, tc.wantSizes) {
+				t.Fatalf("expected requests with %v changes, got %v", tc.wantSizes, sizes)
+			}
+			for i, err := range errs {
+				if (err != nil) != tc.wantErrs[i] {
+					t.Fatalf("expected error %t for request %d, got %v", tc.wantErrs[i], i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeBatcherWaitsForCanceledRequests(t *testing.T) {
+	b := newTestBatcher()
+	client := &fakeChangeClient{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var canceledErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, canceledErr = b.change(ctx, client, changeInput("a.example.com."))
+	}()
+	deadline := time.Now().Add(changeBatchWindow / 2)
+	for b.pendingChanges("Z1") != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the canceled request is part of the combined request, so it gets its result
+	cancel()
+	errs := changeConcurrently(t, b, client, changeInput("b.example.com."))
+	<-done
+
+	if canceledErr != nil || errs[0] != nil {
+		t.Fatalf("unexpected errors: %v, %v", canceledErr, errs[0])
+	}
+	if sizes := client.callSizes(); !equalSizes(sizes, []int{2}) {
+		t.Fatalf("expected a single request with 2 changes, got %v", sizes)
+	}
+}
@@ -0,0 +1,182 @@
+package route53
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+)
+
+// reconcileDelegationSet returns the ID of the reusable delegation set which should be used for
+// the workload cluster zone. The delegation set is created if it is managed by the operator and
+// does not exist yet. An empty ID means that Route53 assigns new name servers to the zone.
+func (s *Service) reconcileDelegationSet(ctx context.Context) (string, error) {
+	// reusable delegation sets can't be used with private zones
+	if s.scope.PrivateZone() {
+		return "", nil
+	}
+
+	if s.scope.DelegationSetID() != "" {
+		return s.scope.DelegationSetID(), nil
+	}
+
+	if s.scope.DelegationSetReference() == "" {
+		return "", nil
+	}
+
+	// concurrent reconciles of clusters sharing the reference must not create a delegation set each
+	unlock := lockDelegationSetReference(s.scope.DelegationSetReference())
+	defer unlock()
+
+	delegationSet, err := s.describeDelegationSet(ctx)
+	if IsNotFound(err) {
+		// fall through
+	} else if err != nil {
+		return "", err
+	} else {
+		return aws.StringValue(delegationSet.Id), nil
+	}
+
+	callerReference, err := delegationSetCallerReference(s.scope.DelegationSetReference())
+	if err != nil {
+		return "", err
+	}
+	out, err := s.Route53Client.CreateReusableDelegationSetWithContext(ctx, &route53.CreateReusableDelegationSetInput{
+		CallerReference: aws.String(callerReference),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create reusable delegation set for cluster %s", s.scope.Name())
+	}
+	createdID := aws.StringValue(out.DelegationSet.Id)
+	s.logger().Info("Created reusable delegation set", "delegationSetID", createdID)
+
+	// another operator instance, e.g. during a leader election handover, may have created a delegation set
+	// for the reference at the same time. All of them use the first one listed and delete the others.
+	delegationSet, err = s.describeDelegationSet(ctx)
+	if err != nil {
+		return "", err
+	}
+	if id := aws.StringValue(delegationSet.Id); id != createdID {
+		_, err = s.Route53Client.DeleteReusableDelegationSetWithContext(ctx, &route53.DeleteReusableDelegationSetInput{Id: aws.String(createdID)})
+		if code, ok := awserrors.Code(errors.Cause(err)); ok && code == route53.ErrCodeNoSuchDelegationSet {
+			// deleted already
+		} else if err != nil {
+			return "", errors.Wrapf(err, "failed to delete duplicate reusable delegation set %s", createdID)
+		}
+		s.logger().Info("Deleted duplicate reusable delegation set", "delegationSetID", createdID, "usedDelegationSetID", id)
+		return id, nil
+	}
+
+	return createdID, nil
+}
+
+// deleteDelegationSet deletes the reusable delegation set managed by the operator once no hosted
+// zone is using it anymore. Delegation sets referenced by ID are never deleted.
+func (s *Service) deleteDelegationSet(ctx context.Context) error {
+	if s.scope.PrivateZone() {
+		return nil
+	}
+	return s.deleteUnusedDelegationSet(ctx)
+}
+
+// deleteUnusedDelegationSet deletes the reusable delegation set managed by the operator if no hosted
+// zone uses it, also when the workload cluster zone was migrated to the private DNS mode.
+func (s *Service) deleteUnusedDelegationSet(ctx context.Context) error {
+	if s.scope.DelegationSetID() != "" || s.scope.DelegationSetReference() == "" {
+		return nil
+	}
+
+	delegationSet, err := s.describeDelegationSet(ctx)
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	zones, err := s.Route53Client.ListHostedZonesWithContext(ctx, &route53.ListHostedZonesInput{
+		DelegationSetId: delegationSet.Id,
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return err
+	}
+	if len(zones.HostedZones) > 0 {
+		// still used by other workload cluster zones
+		return nil
+	}
+
+	_, err = s.Route53Client.DeleteReusableDelegationSetWithContext(ctx, &route53.DeleteReusableDelegationSetInput{Id: delegationSet.Id})
+	if code, ok := awserrors.Code(errors.Cause(err)); ok && (code == route53.ErrCodeDelegationSetInUse || code == route53.ErrCodeNoSuchDelegationSet) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to delete reusable delegation set %s", aws.StringValue(delegationSet.Id))
+	}
+	s.logger().Info("Deleted reusable delegation set", "delegationSetID", aws.StringValue(delegationSet.Id))
+
+	return nil
+}
+
+// describeDelegationSet returns the reusable delegation set managed by the operator for the workload cluster.
+func (s *Service) describeDelegationSet(ctx context.Context) (*route53.DelegationSet, error) {
+	input := &route53.ListReusableDelegationSetsInput{}
+	for {
+		out, err := s.Route53Client.ListReusableDelegationSetsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, delegationSet := range out.DelegationSets {
+			reference := aws.StringValue(delegationSet.CallerReference)
+			if i := strings.LastIndex(reference, "/"); i > 0 && reference[:i] == s.scope.DelegationSetReference() {
+				return delegationSet, nil
+			}
+		}
+
+		if !aws.BoolValue(out.IsTruncated) {
+			break
+		}
+		input.Marker = out.NextMarker
+	}
+
+	return nil, NewNotFound(fmt.Sprintf("reusable delegation set %s not found", s.scope.DelegationSetReference()))
+}
+
+var (
+	delegationSetLocksMutex sync.Mutex
+	delegationSetLocks      = map[string]*sync.Mutex{}
+)
+
+// lockDelegationSetReference serializes the creation of reusable delegation sets with the same reference
+// and returns the function releasing the lock.
+func lockDelegationSetReference(reference string) func() {
+	delegationSetLocksMutex.Lock()
+	lock, ok := delegationSetLocks[reference]
+	if !ok {
+		lock = &sync.Mutex{}
+		delegationSetLocks[reference] = lock
+	}
+	delegationSetLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// delegationSetCallerReference returns a new caller reference for the reusable delegation set. Caller
+// references can't be reused, so the reference is suffixed with the creation time and a random part.
+func delegationSetCallerReference(reference string) (string, error) {
+	suffix := make([]byte, 4)
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate caller reference")
+	}
+	return fmt.Sprintf("%s/%d-%s", reference, time.Now().UnixNano(), hex.EncodeToString(suffix)), nil
+}
@@ -0,0 +1,195 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+)
+
+const (
+	dnssecServeSignatureSigning    = "SIGNING"
+	dnssecServeSignatureNotSigning = "NOT_SIGNING"
+	dnssecServeSignatureDeleting   = "DELETING"
+	keySigningKeyStatusActive      = "ACTIVE"
+	keySigningKeyStatusInactive    = "INACTIVE"
+)
+
+// reconcileDNSSEC creates the KMS backed key signing key of the workload cluster zone and enables
+// DNSSEC signing for the zone.
+func (s *Service) reconcileDNSSEC(ctx context.Context) error {
+	hostZoneID, err := s.describeWorkloadClusterZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	ksk, status, err := s.describeKeySigningKey(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+
+	if ksk == nil {
+		input := &route53.CreateKeySigningKeyInput{
+			CallerReference:         aws.String(fmt.Sprintf("%s-%d", s.scope.Name(), time.Now().Unix())),
+			HostedZoneId:            aws.String(hostZoneID),
+			KeyManagementServiceArn: aws.String(s.scope.DNSSECKMSKeyARN()),
+			Name:                    aws.String(key.DNSSECKeySigningKeyName),
+			Status:                  aws.String(keySigningKeyStatusActive),
+		}
+		_, err = s.Route53Client.CreateKeySigningKeyWithContext(ctx, input)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create key signing key for cluster %s", s.scope.Name())
+		}
+		s.logger().Info("Created key signing key")
+	} else if aws.StringValue(ksk.Status) == keySigningKeyStatusInactive {
+		_, err = s.Route53Client.ActivateKeySigningKeyWithContext(ctx, &route53.ActivateKeySigningKeyInput{
+			HostedZoneId: aws.String(hostZoneID),
+			Name:         ksk.Name,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to activate key signing key for cluster %s", s.scope.Name())
+		}
+	}
+
+	if aws.StringValue(status.ServeSignature) == dnssecServeSignatureSigning {
+		return nil
+	}
+
+	_, err = s.Route53Client.EnableHostedZoneDNSSECWithContext(ctx, &route53.EnableHostedZoneDNSSECInput{HostedZoneId: aws.String(hostZoneID)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable DNSSEC for cluster %s", s.scope.Name())
+	}
+	s.logger().Info("Enabled DNSSEC signing")
+	// DNSSEC was turned on again before signing was disabled
+	s.scope.SetDNSSECDisableAfter(time.Time{})
+
+	return nil
+}
+
+// disableDNSSEC turns off DNSSEC signing of the workload cluster zone once DNSSEC was disabled for the
+// cluster. The DS record is removed from the parent zone by the delegation first, signing is disabled
+// when its TTL passed and the key signing key is deleted afterwards, see deleteDNSSEC.
+func (s *Service) disableDNSSEC(ctx context.Context) error {
+	if s.scope.DNSSEC() || !s.dnssecUsed() {
+		return nil
+	}
+
+	hostZoneID, err := s.describeWorkloadClusterZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteDNSSEC(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+	s.logger().Info("Disabled DNSSEC signing")
+
+	return nil
+}
+
+// deleteDNSSEC disables DNSSEC signing for the workload cluster zone and removes its key signing key.
+// The DS record in the parent zone has to be removed before, signing is only disabled once the TTL of
+// the removed DS record passed.
+func (s *Service) deleteDNSSEC(ctx context.Context, hostZoneID string) error {
+	ksk, status, err := s.describeKeySigningKey(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+
+	switch aws.StringValue(status.ServeSignature) {
+	case "", dnssecServeSignatureNotSigning, dnssecServeSignatureDeleting:
+		// signing is disabled already
+	default:
+		// disabling signing while resolvers still cache the DS record makes the zone fail validation
+		if disableAfter, ok := s.scope.DNSSECDisableAfter(); ok && time.Now().Before(disableAfter) {
+			return NewNotReady(fmt.Sprintf("waiting until %s for the removed DS record to expire before disabling DNSSEC", disableAfter.Format(time.RFC3339)))
+		}
+		_, err = s.Route53Client.DisableHostedZoneDNSSECWithContext(ctx, &route53.DisableHostedZoneDNSSECInput{HostedZoneId: aws.String(hostZoneID)})
+		if err != nil {
+			return errors.Wrapf(err, "failed to disable DNSSEC for cluster %s", s.scope.Name())
+		}
+	}
+
+	if ksk == nil {
+		s.scope.SetDNSSECDisableAfter(time.Time{})
+		return nil
+	}
+
+	if aws.StringValue(ksk.Status) == keySigningKeyStatusActive {
+		_, err = s.Route53Client.DeactivateKeySigningKeyWithContext(ctx, &route53.DeactivateKeySigningKeyInput{
+			HostedZoneId: aws.String(hostZoneID),
+			Name:         ksk.Name,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to deactivate key signing key for cluster %s", s.scope.Name())
+		}
+	}
+
+	_, err = s.Route53Client.DeleteKeySigningKeyWithContext(ctx, &route53.DeleteKeySigningKeyInput{
+		HostedZoneId: aws.String(hostZoneID),
+		Name:         ksk.Name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete key signing key for cluster %s", s.scope.Name())
+	}
+	s.logger().Info("Deleted key signing key")
+	s.scope.SetDNSSECDisableAfter(time.Time{})
+
+	return nil
+}
+
+// dnssecUsed returns true if the workload cluster zone may be signed, either because DNSSEC is enabled
+// or because the operator removed its DS record. This saves looking up the DNSSEC status of every zone.
+func (s *Service) dnssecUsed() bool {
+	if s.scope.DNSSEC() {
+		return true
+	}
+	_, ok := s.scope.DNSSECDisableAfter()
+	return ok
+}
+
+// describeDSRecord returns the DS record value of the active key signing key of the workload cluster zone.
+func (s *Service) describeDSRecord(ctx context.Context) (string, error) {
+	hostZoneID, err := s.describeWorkloadClusterZone(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ksk, _, err := s.describeKeySigningKey(ctx, hostZoneID)
+	if err != nil {
+		return "", err
+	}
+	if ksk == nil || aws.StringValue(ksk.Status) != keySigningKeyStatusActive || aws.StringValue(ksk.DSRecord) == "" {
+		return "", NewNotFound(fmt.Sprintf("active key signing key not found for cluster %s", s.scope.Name()))
+	}
+
+	return aws.StringValue(ksk.DSRecord), nil
+}
+
+// describeKeySigningKey returns the key signing key managed by the operator, nil if it does not exist,
+// and the DNSSEC status of the hosted zone.
+func (s *Service) describeKeySigningKey(ctx context.Context, hostZoneID string) (*route53.KeySigningKey, *route53.DNSSECStatus, error) {
+	out, err := s.Route53Client.GetDNSSECWithContext(ctx, &route53.GetDNSSECInput{HostedZoneId: aws.String(hostZoneID)})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status := out.Status
+	if status == nil {
+		status = &route53.DNSSECStatus{}
+	}
+
+	for _, ksk := range out.KeySigningKeys {
+		if aws.StringValue(ksk.Name) == key.DNSSECKeySigningKeyName {
+			return ksk, status, nil
+		}
+	}
+
+	return nil, status, nil
+}
@@ -0,0 +1,180 @@
+package route53
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/go-logr/logr"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+)
+
+// fakeDNSSECScope is a cluster scope with DNSSEC settings, other methods are not implemented.
+type fakeDNSSECScope struct {
+	scope.Route53Scope
+
+	dnssec       bool
+	disableAfter time.Time
+}
+
+func (s *fakeDNSSECScope) DNSSEC() bool { return s.dnssec }
+
+func (s *fakeDNSSECScope) DNSSECDisableAfter() (time.Time, bool) {
+	return s.disableAfter, !s.disableAfter.IsZero()
+}
+
+func (s *fakeDNSSECScope) SetDNSSECDisableAfter(t time.Time) { s.disableAfter = t }
+
+func (s *fakeDNSSECScope) Name() string { return "test" }
+
+func (s *fakeDNSSECScope) Logger() logr.Logger { return logr.Discard() }
+
+func (s *fakeDNSSECScope) InfraCluster() cloud.ClusterObject { return nil }
+
+// fakeDNSSECClient serves a signed zone and its DS record in the parent zone and records the calls
+// changing them.
+type fakeDNSSECClient struct {
+	route53iface.Route53API
+
+	mutex    sync.Mutex
+	calls    []string
+	signing  bool
+	ksk      *route53.KeySigningKey
+	dsRecord *route53.ResourceRecordSet
+}
+
+func (c *fakeDNSSECClient) record(call string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+func (c *fakeDNSSECClient) GetDNSSECWithContext(_ aws.Context, _ *route53.GetDNSSECInput, _ ...request.Option) (*route53.GetDNSSECOutput, error) {
+	status := dnssecServeSignatureNotSigning
+	if c.signing {
+		status = dnssecServeSignatureSigning
+	}
+	out := &route53.GetDNSSECOutput{Status: &route53.DNSSECStatus{ServeSignature: aws.String(status)}}
+	if c.ksk != nil {
+		out.KeySigningKeys = []*route53.KeySigningKey{c.ksk}
+	}
+	return out, nil
+}
+
+func (c *fakeDNSSECClient) DisableHostedZoneDNSSECWithContext(_ aws.Context, _ *route53.DisableHostedZoneDNSSECInput, _ ...request.Option) (*route53.DisableHostedZoneDNSSECOutput, error) {
+	c.record("DisableHostedZoneDNSSEC")
+	c.signing = false
+	return &route53.DisableHostedZoneDNSSECOutput{}, nil
+}
+
+func (c *fakeDNSSECClient) DeactivateKeySigningKeyWithContext(_ aws.Context, _ *route53.DeactivateKeySigningKeyInput, _ ...request.Option) (*route53.DeactivateKeySigningKeyOutput, error) {
+	c.record("DeactivateKeySigningKey")
+	c.ksk.Status = aws.String(keySigningKeyStatusInactive)
+	return &route53.DeactivateKeySigningKeyOutput{}, nil
+}
+
+func (c *fakeDNSSECClient) DeleteKeySigningKeyWithContext(_ aws.Context, _ *route53.DeleteKeySigningKeyInput, _ ...request.Option) (*route53.DeleteKeySigningKeyOutput, error) {
+	c.record("DeleteKeySigningKey")
+	c.ksk = nil
+	return &route53.DeleteKeySigningKeyOutput{}, nil
+}
+
+func (c *fakeDNSSECClient) ListResourceRecordSetsWithContext(_ aws.Context, _ *route53.ListResourceRecordSetsInput, _ ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
+	out := &route53.ListResourceRecordSetsOutput{}
+	if c.dsRecord != nil {
+		out.ResourceRecordSets = []*route53.ResourceRecordSet{c.dsRecord}
+	}
+	return out, nil
+}
+
+func (c *fakeDNSSECClient) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	for _, change := range input.ChangeBatch.Changes {
+		c.record(aws.StringValue(change.Action) + " " + aws.StringValue(change.ResourceRecordSet.Type))
+		if aws.StringValue(change.Action) == route53.ChangeActionDelete {
+			c.dsRecord = nil
+		}
+	}
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("change")}}, nil
+}
+
+func TestDisableDNSSEC(t *testing.T) {
+	recordName := "test.example.com."
+	client := &fakeDNSSECClient{
+		signing: true,
+		ksk: &route53.KeySigningKey{
+			Name:   aws.String(key.DNSSECKeySigningKeyName),
+			Status: aws.String(keySigningKeyStatusActive),
+		},
+		dsRecord: &route53.ResourceRecordSet{
+			Name:            aws.String(recordName),
+			Type:            aws.String(route53.RRTypeDs),
+			TTL:             aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("12345 13 2 ABCDEF")}},
+		},
+	}
+	// DNSSEC was turned off for the signed zone
+	clusterScope := &fakeDNSSECScope{}
+	s := &Service{
+		scope:                   clusterScope,
+		Route53Client:           client,
+		ManagementRoute53Client: client,
+		workloadZoneID:          "Z1",
+	}
+	ctx := context.Background()
+
+	// the delegation removes the DS record from the parent zone
+	err := s.deleteDelegationRecord(ctx, "ZPARENT", recordName, route53.RRTypeDs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disableAfter, ok := clusterScope.DNSSECDisableAfter()
+	if !ok || time.Until(disableAfter) < 4*time.Minute {
+		t.Fatalf("expected signing to be disabled after the DS record TTL, got %s", disableAfter)
+	}
+
+	// signing stays enabled while resolvers may cache the DS record
+	err = s.disableDNSSEC(ctx)
+	if !IsNotReady(err) {
+		t.Fatalf("expected not ready while the DS record is cached, got %v", err)
+	}
+	if !client.signing || client.ksk == nil {
+		t.Fatal("expected signing to stay enabled while the DS record is cached")
+	}
+
+	// the TTL of the DS record passed
+	clusterScope.disableAfter = time.Now().Add(-time.Second)
+	err = s.disableDNSSEC(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := clusterScope.DNSSECDisableAfter(); ok {
+		t.Fatal("expected the disable after time to be removed")
+	}
+
+	want := []string{"DELETE DS", "DisableHostedZoneDNSSEC", "DeactivateKeySigningKey", "DeleteKeySigningKey"}
+	if len(client.calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, client.calls)
+	}
+	for i := range want {
+		if client.calls[i] != want[i] {
+			t.Fatalf("expected calls %v, got %v", want, client.calls)
+		}
+	}
+
+	// nothing is left to disable
+	err = s.disableDNSSEC(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.calls) != len(want) {
+		t.Fatalf("expected no further calls, got %v", client.calls[len(want):])
+	}
+}
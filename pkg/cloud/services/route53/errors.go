@@ -4,16 +4,73 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
 	"github.com/pkg/errors"
 
 	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
 )
 
 var _ error = &Route53Error{}
 
+// ErrorClass describes how an error of the service is handled by the reconciler.
+type ErrorClass string
+
+const (
+	// ErrorClassThrottled errors are caused by AWS throttling, they are retried with backoff.
+	ErrorClassThrottled ErrorClass = "Throttled"
+	// ErrorClassAccessDenied errors are caused by missing permissions or invalid credentials, they need user action.
+	ErrorClassAccessDenied ErrorClass = "AccessDenied"
+	// ErrorClassInvalidInput errors are caused by invalid configuration, e.g. record templates, they need user action.
+	ErrorClassInvalidInput ErrorClass = "InvalidInput"
+	// ErrorClassNotReady errors are returned while the cluster infrastructure, e.g. the VPC, is not ready yet.
+	ErrorClassNotReady ErrorClass = "NotReady"
+	// ErrorClassConflict errors are caused by concurrent changes or resources in use, they are retried with backoff.
+	ErrorClassConflict ErrorClass = "Conflict"
+	// ErrorClassUnknown errors are all other errors, they are retried with backoff.
+	ErrorClassUnknown ErrorClass = "Unknown"
+)
+
+var accessDeniedCodes = map[string]bool{
+	"AccessDenied": true,
+	route53resolver.ErrCodeAccessDeniedException: true,
+	route53.ErrCodeNotAuthorizedException:        true,
+	"InvalidClientTokenId":                       true,
+	"NoCredentialProviders":                      true,
+	"SignatureDoesNotMatch":                      true,
+	"UnrecognizedClientException":                true,
+}
+
+var invalidInputCodes = map[string]bool{
+	request.InvalidParameterErrCode:                         true,
+	route53.ErrCodeInvalidArgument:                          true,
+	route53.ErrCodeInvalidChangeBatch:                       true,
+	route53.ErrCodeInvalidDomainName:                        true,
+	route53.ErrCodeInvalidInput:                             true,
+	route53.ErrCodeInvalidKMSArn:                            true,
+	route53.ErrCodeInvalidVPCId:                             true,
+	route53.ErrCodeNoSuchCloudWatchLogsLogGroup:             true,
+	route53.ErrCodeInsufficientCloudWatchLogsResourcePolicy: true,
+	route53resolver.ErrCodeInvalidParameterException:        true,
+	route53resolver.ErrCodeInvalidRequestException:          true,
+	route53resolver.ErrCodeValidationException:              true,
+}
+
+var conflictCodes = map[string]bool{
+	route53.ErrCodeConcurrentModification:         true,
+	route53.ErrCodeConflictingDomainExists:        true,
+	route53.ErrCodeConflictingTypes:               true,
+	route53.ErrCodeDelegationSetInUse:             true,
+	route53.ErrCodeHealthCheckInUse:               true,
+	route53.ErrCodeHostedZoneNotEmpty:             true,
+	route53.ErrCodeKeySigningKeyInUse:             true,
+	route53resolver.ErrCodeConflictException:      true,
+	route53resolver.ErrCodeResourceInUseException: true,
+}
+
 // Route53Error is an error exposed to users of this library.
 type Route53Error struct {
 	msg string
@@ -34,6 +91,14 @@ func NewNotFound(msg string) error {
 	}
 }
 
+// NewNotReady returns an error which indicates that the cluster infrastructure is not ready yet.
+func NewNotReady(msg string) error {
+	return &Route53Error{
+		msg:  msg,
+		Code: http.StatusPreconditionFailed,
+	}
+}
+
 // NewConflict returns an error which indicates that the request cannot be processed due to a conflict.
 func NewConflict(msg string) error {
 	return &Route53Error{
@@ -42,39 +107,89 @@ func NewConflict(msg string) error {
 	}
 }
 
-// IsNotFound returns true if the error was created by NewNotFound.
+// NewInvalidConfiguration returns an error which indicates that the cluster configuration needs user action.
+func NewInvalidConfiguration(msg string) error {
+	return &Route53Error{
+		msg:  msg,
+		Code: http.StatusBadRequest,
+	}
+}
+
+// IsNotFound returns true if the error was created by NewNotFound or Route53 did not find a hosted zone by name.
+// Requests for hosted zone IDs which don't exist fail with NoSuchHostedZone instead, see isNoSuchHostedZone.
 func IsNotFound(err error) bool {
 	if ReasonForError(err) == http.StatusNotFound {
 		return true
 	}
-	if err == aws.ErrMissingEndpoint {
-		return true
-	}
 	if code, ok := awserrors.Code(errors.Cause(err)); ok {
-		if code == route53.ErrCodeHostedZoneNotFound || code == route53.ErrCodeInvalidChangeBatch {
+		if code == route53.ErrCodeHostedZoneNotFound {
 			return true
 		}
 	}
 	return false
 }
 
+// IsNotReady returns true if the error was created by NewNotReady.
+func IsNotReady(err error) bool {
+	return ReasonForError(err) == http.StatusPreconditionFailed
+}
+
+// IsThrottled returns true if the request was throttled by AWS.
+func IsThrottled(err error) bool {
+	return request.IsErrorThrottle(errors.Cause(err))
+}
+
+// IsInvalidInput returns true if the error was created by NewInvalidConfiguration, the cluster has invalid
+// annotations or AWS rejected the request because of invalid parameters.
+func IsInvalidInput(err error) bool {
+	if ReasonForError(err) == http.StatusBadRequest || scope.IsInvalidAnnotations(err) {
+		return true
+	}
+	code, ok := awserrors.Code(errors.Cause(err))
+	return ok && invalidInputCodes[code]
+}
+
 func IsAlreadyExists(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "it already exists")
 }
 
-// IsAccessDenied returns true if the error is AccessDenied.
+// IsAccessDenied returns true if the request was denied because of missing permissions or invalid credentials,
+// or the identity of the cluster doesn't allow its namespace.
 func IsAccessDenied(err error) bool {
-	if code, ok := awserrors.Code(errors.Cause(err)); ok {
-		if code == "AccessDenied" {
-			return true
-		}
+	if scope.IsIdentityNotAllowed(err) {
+		return true
 	}
-	return false
+	code, ok := awserrors.Code(errors.Cause(err))
+	return ok && accessDeniedCodes[code]
 }
 
-// IsConflict returns true if the error was created by NewConflict.
+// IsConflict returns true if the error was created by NewConflict or AWS rejected the request
+// because of concurrent changes or resources in use.
 func IsConflict(err error) bool {
-	return ReasonForError(err) == http.StatusConflict
+	if ReasonForError(err) == http.StatusConflict {
+		return true
+	}
+	code, ok := awserrors.Code(errors.Cause(err))
+	return ok && conflictCodes[code]
+}
+
+// ClassifyError returns the class of the error, which decides how the reconciler retries it and
+// how it is reported.
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case IsThrottled(err):
+		return ErrorClassThrottled
+	case IsAccessDenied(err):
+		return ErrorClassAccessDenied
+	case IsInvalidInput(err):
+		return ErrorClassInvalidInput
+	case IsNotReady(err):
+		return ErrorClassNotReady
+	case IsConflict(err):
+		return ErrorClassConflict
+	default:
+		return ErrorClassUnknown
+	}
 }
 
 // IsSDKError returns true if the error is of type awserr.Error.
@@ -0,0 +1,73 @@
+package route53
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/record"
+)
+
+// Reasons of the events emitted on the infrastructure cluster.
+const (
+	eventHostedZoneCreated = "HostedZoneCreated"
+	eventHostedZoneDeleted = "HostedZoneDeleted"
+	eventRecordsChanged    = "RecordsChanged"
+	eventRecordsDeleted    = "RecordsDeleted"
+	eventDelegationChanged = "DelegationChanged"
+	eventDelegationDeleted = "DelegationDeleted"
+	eventVPCAssociated     = "VPCAssociated"
+	eventDNSModeMigrated   = "DNSModeMigrated"
+)
+
+// recordChangesEvent emits an event summarizing the submitted changes of the workload cluster zone,
+// e.g. `updated A api.example.com. from 10.0.0.1 to 10.0.0.2`. current are the record sets before the changes.
+func (s *Service) recordChangesEvent(changes []*route53.Change, current []*route53.ResourceRecordSet) {
+	var summary []string
+	for _, change := range changes {
+		desired := change.ResourceRecordSet
+		existing := findRecordSetWithIdentifier(current, desired)
+		switch {
+		case aws.StringValue(change.Action) == route53.ChangeActionDelete:
+			summary = append(summary, fmt.Sprintf("deleted %s", describeRecordSet(desired)))
+		case existing == nil:
+			summary = append(summary, fmt.Sprintf("created %s with %s", describeRecordSet(desired), recordSetValues(desired)))
+		default:
+			summary = append(summary, fmt.Sprintf("updated %s from %s to %s", describeRecordSet(desired), recordSetValues(existing), recordSetValues(desired)))
+		}
+	}
+
+	record.Eventf(s.scope.InfraCluster(), eventRecordsChanged, "Changed DNS records of hosted zone %s: %s", s.workloadClusterZoneName(), strings.Join(summary, "; "))
+}
+
+func findRecordSetWithIdentifier(recordSets []*route53.ResourceRecordSet, recordSet *route53.ResourceRecordSet) *route53.ResourceRecordSet {
+	for _, r := range recordSets {
+		if normalizeRecordName(aws.StringValue(r.Name)) == normalizeRecordName(aws.StringValue(recordSet.Name)) &&
+			aws.StringValue(r.Type) == aws.StringValue(recordSet.Type) &&
+			aws.StringValue(r.SetIdentifier) == aws.StringValue(recordSet.SetIdentifier) {
+			return r
+		}
+	}
+	return nil
+}
+
+func describeRecordSet(r *route53.ResourceRecordSet) string {
+	description := fmt.Sprintf("%s %s", aws.StringValue(r.Type), aws.StringValue(r.Name))
+	if r.SetIdentifier != nil {
+		description += fmt.Sprintf(" (%s)", aws.StringValue(r.SetIdentifier))
+	}
+	return description
+}
+
+func recordSetValues(r *route53.ResourceRecordSet) string {
+	if r.AliasTarget != nil {
+		return fmt.Sprintf("alias %s", aws.StringValue(r.AliasTarget.DNSName))
+	}
+	var values []string
+	for _, v := range r.ResourceRecords {
+		values = append(values, aws.StringValue(v.Value))
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,180 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	gsannotations "github.com/giantswarm/k8smetadata/pkg/annotation"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+	"github.com/giantswarm/dns-operator-aws/pkg/record"
+)
+
+// modeMigration tracks the migration of the workload cluster zone to another DNS mode. The zone of the
+// new mode is reconciled like any other zone, so its records and delegation are in place before the
+// zone of the previous mode is deleted.
+type modeMigration struct {
+	// previousZoneID is the ID of the zone in the previous DNS mode
+	previousZoneID string
+	// step is the reason of the DNSModeMigrated condition for the last step started by the reconcile
+	step      string
+	completed bool
+}
+
+// zoneMode returns the DNS mode of a public or private zone.
+func zoneMode(private bool) string {
+	if private {
+		return gsannotations.DNSModePrivate
+	}
+	return key.DNSModePublic
+}
+
+// describePreviousModeZone looks for a zone of the workload cluster in the other DNS mode. Such a zone is
+// only replaced when it is owned by the cluster and the migration is requested with the
+// aws.giantswarm.io/dns-mode-migration annotation.
+func (s *Service) describePreviousModeZone(ctx context.Context) error {
+	previousZoneID, err := s.describeWorkloadClusterZoneByName(ctx, !s.scope.PrivateZone())
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// a zone of the same name in the other mode which wasn't created for the cluster, e.g. the other half
+	// of a split-horizon setup, is neither replaced nor does it block the zone of the cluster
+	owned, err := s.zoneOwned(ctx, previousZoneID)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		s.logger().V(1).Info("Ignoring hosted zone of other DNS mode not owned by the cluster", "hostedZoneID", previousZoneID)
+		return nil
+	}
+
+	previousMode, mode := zoneMode(!s.scope.PrivateZone()), zoneMode(s.scope.PrivateZone())
+	if !s.scope.DNSModeMigration() {
+		s.migration = &modeMigration{previousZoneID: previousZoneID, step: key.MigrationNotAllowedReason}
+		return NewInvalidConfiguration(fmt.Sprintf("hosted zone %s exists in %s mode, set the %s annotation to migrate it to %s mode",
+			previousZoneID, previousMode, key.DNSModeMigrationAnnotation, mode))
+	}
+
+	s.migration = &modeMigration{previousZoneID: previousZoneID}
+	s.logger().Info("Migrating hosted DNS zone", "previousZoneID", previousZoneID, "previousMode", previousMode, "mode", mode)
+	return nil
+}
+
+// modeMigrationRequested returns true if the migration annotation is set and no migration completed since
+// the DNS mode changed, i.e. the DNSModeMigrated condition isn't true. A completed migration doesn't look up
+// the zone of the other mode again while the annotation is left over.
+func (s *Service) modeMigrationRequested() bool {
+	return s.scope.DNSModeMigration() && !conditions.IsTrue(s.scope.InfraCluster(), key.DNSModeMigrated)
+}
+
+// setMigrationStep records the step reached by a running migration.
+func (s *Service) setMigrationStep(reason string) {
+	if s.migration != nil {
+		s.migration.step = reason
+	}
+}
+
+// completeModeMigration deletes the zone of the previous DNS mode once the zone of the new mode is reconciled.
+func (s *Service) completeModeMigration(ctx context.Context) error {
+	err := s.deletePreviousModeZone(ctx, s.migration.previousZoneID)
+	if err != nil {
+		return err
+	}
+
+	s.migration.completed = true
+	s.logger().Info("Migrated hosted DNS zone", "previousZoneID", s.migration.previousZoneID, "mode", zoneMode(s.scope.PrivateZone()))
+	record.Eventf(s.scope.InfraCluster(), eventDNSModeMigrated, "Migrated hosted zone %s to %s mode, replaced hosted zone %s, the %s annotation can be removed",
+		s.workloadClusterZoneName(), zoneMode(s.scope.PrivateZone()), s.migration.previousZoneID, key.DNSModeMigrationAnnotation)
+
+	return nil
+}
+
+// zoneOwned returns true if the hosted zone carries the ownership tag set by createWorkloadClusterZone
+// for the workload cluster. Zones of the same name created outside of the operator are left alone.
+func (s *Service) zoneOwned(ctx context.Context, hostZoneID string) (bool, error) {
+	out, err := s.Route53Client.ListTagsForResourceWithContext(ctx, &route53.ListTagsForResourceInput{
+		ResourceId:   aws.String(strings.TrimPrefix(hostZoneID, "/hostedzone/")),
+		ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list tags of hosted zone %s", hostZoneID)
+	}
+	ownerKey := fmt.Sprintf("sigs.k8s.io/cluster-api-provider-aws/cluster/%s", s.scope.Name())
+	for _, tag := range out.ResourceTagSet.Tags {
+		if aws.StringValue(tag.Key) == ownerKey && aws.StringValue(tag.Value) == "owned" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deletePreviousModeZone deletes the zone of the workload cluster in the other DNS mode along with
+// its delegation, DNSSEC signing, query logging, records and health checks.
+func (s *Service) deletePreviousModeZone(ctx context.Context, hostZoneID string) error {
+	previousPrivate := !s.scope.PrivateZone()
+
+	if !previousPrivate {
+		// the previous public zone is delegated from the parent zone, a private zone isn't
+		s.setMigrationStep(key.SwitchingDelegationReason)
+		err := s.changeManagementClusterDelegation(ctx, route53.ChangeActionDelete)
+		if IsNotFound(err) {
+			// parent zone is gone, continue with the previous zone
+		} else if err != nil {
+			return err
+		}
+	}
+
+	s.setMigrationStep(key.DeletingPreviousZoneReason)
+	if previousPrivate {
+		err := s.deleteResolverQueryLogging(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		// DNSSEC can only be disabled once the DS record is removed from the parent zone
+		if s.dnssecUsed() {
+			err := s.deleteDNSSEC(ctx, hostZoneID)
+			if err != nil {
+				return err
+			}
+		}
+		err := s.deleteZoneQueryLogging(ctx, hostZoneID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// health checks are referenced by the api records, so they are collected before the records are gone
+	healthCheckIDs, err := s.listAPIHealthChecks(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+	err = s.deleteAllWorkloadClusterRecords(ctx, hostZoneID, route53.ChangeActionDelete)
+	if err != nil {
+		return err
+	}
+	err = s.deleteHealthChecks(ctx, healthCheckIDs)
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteWorkloadClusterZone(ctx, hostZoneID)
+	if isNoSuchHostedZone(err) {
+		// zone is gone already
+	} else if err != nil {
+		return err
+	}
+
+	if !previousPrivate {
+		return s.deleteUnusedDelegationSet(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package route53
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+)
+
+// maxResolverQueryLogConfigNameLength is the maximum length of resolver query log config names.
+const maxResolverQueryLogConfigNameLength = 64
+
+// reconcileQueryLogging configures DNS query logging for the workload cluster zone. Public zones get a
+// Route53 query logging config, private zones a Route53 Resolver query logging config associated
+// with the workload cluster VPC.
+func (s *Service) reconcileQueryLogging(ctx context.Context, hostZoneID string) error {
+	if s.scope.PrivateZone() {
+		return s.reconcileResolverQueryLogging(ctx)
+	}
+
+	if s.scope.QueryLoggingLogGroupARN() == "" {
+		return nil
+	}
+
+	configs, err := s.listQueryLoggingConfigs(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if aws.StringValue(config.CloudWatchLogsLogGroupArn) == s.scope.QueryLoggingLogGroupARN() {
+			return nil
+		}
+		// a hosted zone can only have a single query logging config
+		_, err = s.Route53Client.DeleteQueryLoggingConfigWithContext(ctx, &route53.DeleteQueryLoggingConfigInput{Id: config.Id})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete outdated query logging config for cluster %s", s.scope.Name())
+		}
+	}
+
+	input := &route53.CreateQueryLoggingConfigInput{
+		CloudWatchLogsLogGroupArn: aws.String(s.scope.QueryLoggingLogGroupARN()),
+		HostedZoneId:              aws.String(hostZoneID),
+	}
+	_, err = s.Route53Client.CreateQueryLoggingConfigWithContext(ctx, input)
+	if code, ok := awserrors.Code(errors.Cause(err)); ok && (code == route53.ErrCodeNoSuchCloudWatchLogsLogGroup || code == route53.ErrCodeInsufficientCloudWatchLogsResourcePolicy) {
+		// the log group is looked up in the account of the hosted zone, so a single log group can't
+		// serve workload clusters in other accounts
+		return NewInvalidConfiguration(fmt.Sprintf("log group %s can't be used for query logging of cluster %s, it has to be in us-east-1 in the AWS account of the workload cluster and allow route53.amazonaws.com to write to it: %s",
+			s.scope.QueryLoggingLogGroupARN(), s.scope.Name(), err))
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to create query logging config for cluster %s", s.scope.Name())
+	}
+	s.logger().Info("Created query logging config")
+
+	return nil
+}
+
+// deleteQueryLogging removes the query logging configuration of the workload cluster zone.
+func (s *Service) deleteQueryLogging(ctx context.Context, hostZoneID string) error {
+	if !s.queryLoggingEnabled() {
+		return nil
+	}
+
+	if s.scope.PrivateZone() {
+		return s.deleteResolverQueryLogging(ctx)
+	}
+
+	return s.deleteZoneQueryLogging(ctx, hostZoneID)
+}
+
+// deleteZoneQueryLogging removes the Route53 query logging configs of a public zone.
+func (s *Service) deleteZoneQueryLogging(ctx context.Context, hostZoneID string) error {
+	if !s.queryLoggingEnabled() {
+		return nil
+	}
+
+	configs, err := s.listQueryLoggingConfigs(ctx, hostZoneID)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		_, err = s.Route53Client.DeleteQueryLoggingConfigWithContext(ctx, &route53.DeleteQueryLoggingConfigInput{Id: config.Id})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete query logging config for cluster %s", s.scope.Name())
+		}
+	}
+
+	return nil
+}
+
+// queryLoggingEnabled returns true if any kind of query logging is configured. Query logging configs are
+// only looked up for deletion then, configs created before query logging was disabled are left over.
+func (s *Service) queryLoggingEnabled() bool {
+	return s.scope.QueryLoggingLogGroupARN() != "" || s.scope.ResolverQueryLogDestination() != ""
+}
+
+func (s *Service) listQueryLoggingConfigs(ctx context.Context, hostZoneID string) ([]*route53.QueryLoggingConfig, error) {
+	out, err := s.Route53Client.ListQueryLoggingConfigsWithContext(ctx, &route53.ListQueryLoggingConfigsInput{HostedZoneId: aws.String(hostZoneID)})
+	if err != nil {
+		return nil, err
+	}
+	return out.QueryLoggingConfigs, nil
+}
+
+func (s *Service) reconcileResolverQueryLogging(ctx context.Context) error {
+	if s.scope.ResolverQueryLogDestination() == "" || s.scope.VPC() == "" {
+		return nil
+	}
+
+	config, err := s.describeResolverQueryLogConfig(ctx)
+	if IsNotFound(err) {
+		input := &route53resolver.CreateResolverQueryLogConfigInput{
+			DestinationArn: aws.String(s.scope.ResolverQueryLogDestination()),
+			Name:           aws.String(s.resolverQueryLogConfigName()),
+		}
+		out, err := s.Route53ResolverClient.CreateResolverQueryLogConfigWithContext(ctx, input)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create resolver query log config for cluster %s", s.scope.Name())
+		}
+		s.logger().Info("Created resolver query log config", "resolverQueryLogConfigID", aws.StringValue(out.ResolverQueryLogConfig.Id))
+		config = out.ResolverQueryLogConfig
+	} else if err != nil {
+		return err
+	}
+
+	association, err := s.describeResolverQueryLogConfigAssociation(ctx, config.Id)
+	if err != nil {
+		return err
+	}
+	if association != nil {
+		return nil
+	}
+
+	_, err = s.Route53ResolverClient.AssociateResolverQueryLogConfigWithContext(ctx, &route53resolver.AssociateResolverQueryLogConfigInput{
+		ResolverQueryLogConfigId: config.Id,
+		ResourceId:               aws.String(s.scope.VPC()),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to associate resolver query log config with vpc %s, for cluster %s", s.scope.VPC(), s.scope.Name())
+	}
+
+	return nil
+}
+
+func (s *Service) deleteResolverQueryLogging(ctx context.Context) error {
+	if !s.queryLoggingEnabled() {
+		return nil
+	}
+
+	config, err := s.describeResolverQueryLogConfig(ctx)
+	if IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	association, err := s.describeResolverQueryLogConfigAssociation(ctx, config.Id)
+	if err != nil {
+		return err
+	}
+	if association != nil {
+		if aws.StringValue(association.Status) != route53resolver.ResolverQueryLogConfigAssociationStatusDeleting {
+			_, err = s.Route53ResolverClient.DisassociateResolverQueryLogConfigWithContext(ctx, &route53resolver.DisassociateResolverQueryLogConfigInput{
+				ResolverQueryLogConfigId: config.Id,
+				ResourceId:               association.ResourceId,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to disassociate resolver query log config for cluster %s", s.scope.Name())
+			}
+		}
+		// config can only be deleted once the association is gone
+		return NewConflict(fmt.Sprintf("resolver query log config for cluster %s is still associated", s.scope.Name()))
+	}
+
+	_, err = s.Route53ResolverClient.DeleteResolverQueryLogConfigWithContext(ctx, &route53resolver.DeleteResolverQueryLogConfigInput{
+		ResolverQueryLogConfigId: config.Id,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete resolver query log config for cluster %s", s.scope.Name())
+	}
+	s.logger().Info("Deleted resolver query log config", "resolverQueryLogConfigID", aws.StringValue(config.Id))
+
+	return nil
+}
+
+// describeResolverQueryLogConfig returns the resolver query log config of the workload cluster. Configs
+// created before the name included the namespace are only used if they are associated with the
+// workload cluster VPC, so clusters with the same name in other namespaces don't share them.
+func (s *Service) describeResolverQueryLogConfig(ctx context.Context) (*route53resolver.ResolverQueryLogConfig, error) {
+	config, err := s.describeResolverQueryLogConfigByName(ctx, s.resolverQueryLogConfigName())
+	if !IsNotFound(err) || s.scope.VPC() == "" {
+		return config, err
+	}
+
+	legacyConfig, legacyErr := s.describeResolverQueryLogConfigByName(ctx, s.legacyResolverQueryLogConfigName())
+	if IsNotFound(legacyErr) {
+		return nil, err
+	} else if legacyErr != nil {
+		return nil, legacyErr
+	}
+	association, legacyErr := s.describeResolverQueryLogConfigAssociation(ctx, legacyConfig.Id)
+	if legacyErr != nil {
+		return nil, legacyErr
+	}
+	if association == nil {
+		return nil, err
+	}
+	return legacyConfig, nil
+}
+
+func (s *Service) describeResolverQueryLogConfigByName(ctx context.Context, name string) (*route53resolver.ResolverQueryLogConfig, error) {
+	out, err := s.Route53ResolverClient.ListResolverQueryLogConfigsWithContext(ctx, &route53resolver.ListResolverQueryLogConfigsInput{
+		Filters: []*route53resolver.Filter{
+			{
+				Name:   aws.String("Name"),
+				Values: aws.StringSlice([]string{name}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range out.ResolverQueryLogConfigs {
+		if aws.StringValue(config.Name) == name {
+			return config, nil
+		}
+	}
+
+	return nil, NewNotFound(fmt.Sprintf("resolver query log config %s not found", name))
+}
+
+// describeResolverQueryLogConfigAssociation returns the association of the config with the workload
+// cluster VPC, nil if there is none.
+func (s *Service) describeResolverQueryLogConfigAssociation(ctx context.Context, configID *string) (*route53resolver.ResolverQueryLogConfigAssociation, error) {
+	out, err := s.Route53ResolverClient.ListResolverQueryLogConfigAssociationsWithContext(ctx, &route53resolver.ListResolverQueryLogConfigAssociationsInput{
+		Filters: []*route53resolver.Filter{
+			{
+				Name:   aws.String("ResolverQueryLogConfigId"),
+				Values: []*string{configID},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, association := range out.ResolverQueryLogConfigAssociations {
+		if s.scope.VPC() == "" || aws.StringValue(association.ResourceId) == s.scope.VPC() {
+			return association, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// resolverQueryLogConfigName returns the name of the resolver query log config of the workload cluster,
+// which includes the namespace of the cluster. Names longer than the limit of 64 characters are
+// shortened and suffixed with a hash of the namespace and name to keep them unique.
+func (s *Service) resolverQueryLogConfigName() string {
+	namespace := s.scope.InfraCluster().GetNamespace()
+	name := fmt.Sprintf("dns-operator-aws-%s-%s", namespace, s.scope.Name())
+	if len(name) <= maxResolverQueryLogConfigNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(namespace+"/"+s.scope.Name())))[:8]
+	return name[:maxResolverQueryLogConfigNameLength-len(hash)-1] + "-" + hash
+}
+
+// legacyResolverQueryLogConfigName returns the name of resolver query log configs created before the name
+// included the namespace of the cluster.
+func (s *Service) legacyResolverQueryLogConfigName() string {
+	return fmt.Sprintf("dns-operator-aws-%s", s.scope.Name())
+}
@@ -1,20 +1,65 @@
 package route53
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/dnsverifier"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+	"github.com/giantswarm/dns-operator-aws/pkg/record"
+	"github.com/giantswarm/dns-operator-aws/pkg/records"
 )
 
-func (s *Service) DeleteRoute53() error {
-	s.scope.Logger().V(2).Info("Deleting hosted DNS zone")
-	hostedZoneID, err := s.describeWorkloadClusterZone()
+func (s *Service) DeleteRoute53(ctx context.Context) (err error) {
+	defer func() { s.forgetHostedZones(err) }()
+
+	return s.traceStep(ctx, "DeleteRoute53", s.deleteRoute53)
+}
+
+func (s *Service) deleteRoute53(ctx context.Context) error {
+	s.logger().V(1).Info("Deleting hosted DNS zone")
+
+	// a zone of the previous DNS mode is left over when the cluster is deleted during a migration
+	err := s.traceStep(ctx, "DeletePreviousModeZone", func(ctx context.Context) error {
+		previousZoneID, err := s.describeWorkloadClusterZoneByName(ctx, !s.scope.PrivateZone())
+		if IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		owned, err := s.zoneOwned(ctx, previousZoneID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			s.logger().Info("Keeping hosted zone of other DNS mode not owned by the cluster", "hostedZoneID", previousZoneID)
+			return nil
+		}
+		return s.deletePreviousModeZone(ctx, previousZoneID)
+	})
+	if err != nil {
+		return err
+	}
+
+	var hostedZoneID string
+	err = s.traceStep(ctx, "DescribeWorkloadClusterZone", func(ctx context.Context) (err error) {
+		hostedZoneID, err = s.describeWorkloadClusterZone(ctx)
+		return err
+	})
 	if IsNotFound(err) {
-		return nil
+		// zone might be gone already while its delegation set or resolver query logging is left over
+		if s.scope.PrivateZone() {
+			return s.traceStep(ctx, "DeleteResolverQueryLogging", s.deleteResolverQueryLogging)
+		}
+		return s.traceStep(ctx, "DeleteDelegationSet", s.deleteDelegationSet)
 	} else if err != nil {
 		return err
 	}
@@ -22,313 +67,725 @@ func (s *Service) DeleteRoute53() error {
 	// delegation is only done for public zones
 	if !s.scope.PrivateZone() {
 		// First delete delegation record from managament
-		err = s.changeManagementClusterDelegation("DELETE")
+		err = s.traceStep(ctx, "DeleteDelegation", func(ctx context.Context) error {
+			return s.changeManagementClusterDelegation(ctx, route53.ChangeActionDelete)
+		})
 		if IsNotFound(err) {
-			return nil
+			// parent zone is gone, continue with the workload cluster zone
 		} else if err != nil {
 			return err
 		}
+
+		// DNSSEC can only be disabled once the DS record is removed from the parent zone
+		if s.dnssecUsed() {
+			err = s.traceStep(ctx, "DeleteDNSSEC", func(ctx context.Context) error {
+				return s.deleteDNSSEC(ctx, hostedZoneID)
+			})
+			if err != nil {
+				return err
+			}
+		}
 	}
+
+	err = s.traceStep(ctx, "DeleteQueryLogging", func(ctx context.Context) error {
+		return s.deleteQueryLogging(ctx, hostedZoneID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// health checks are referenced by the api records, so they are collected before the records are gone
+	healthCheckIDs, err := s.listAPIHealthChecks(ctx, hostedZoneID)
+	if err != nil {
+		return err
+	}
+
 	// We need to delete all records first before we can delete the hosted zone
-	err = s.deleteAllWorkloadClusterRecords("DELETE")
+	err = s.traceStep(ctx, "DeleteRecords", func(ctx context.Context) error {
+		return s.deleteAllWorkloadClusterRecords(ctx, hostedZoneID, route53.ChangeActionDelete)
+	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to delete")
 	}
 
+	err = s.traceStep(ctx, "DeleteHealthChecks", func(ctx context.Context) error {
+		return s.deleteHealthChecks(ctx, healthCheckIDs)
+	})
+	if err != nil {
+		return err
+	}
+
 	// Finally delete DNS zone for workload cluster
-	err = s.deleteWorkloadClusterZone(hostedZoneID)
+	err = s.traceStep(ctx, "DeleteWorkloadClusterZone", func(ctx context.Context) error {
+		return s.deleteWorkloadClusterZone(ctx, hostedZoneID)
+	})
 	if IsNotFound(err) {
 		return nil
 	} else if err != nil {
 		return err
 	}
-	s.scope.Logger().V(2).Info(fmt.Sprintf("Deleting hosted zone completed successfully for cluster %s", s.scope.Name()))
+	s.forgetWorkloadClusterZone()
+
+	err = s.traceStep(ctx, "DeleteDelegationSet", s.deleteDelegationSet)
+	if err != nil {
+		return err
+	}
+	s.logger().Info("Deleted hosted DNS zone")
 
 	return nil
 }
 
-func (s *Service) ReconcileRoute53() error {
-	s.scope.Logger().Info("Reconciling hosted DNS zone")
+func (s *Service) ReconcileRoute53(ctx context.Context) (err error) {
+	defer func() { s.forgetHostedZones(err) }()
+
+	return s.traceStep(ctx, "ReconcileRoute53", s.reconcileRoute53)
+}
+
+func (s *Service) reconcileRoute53(ctx context.Context) error {
+	s.logger().V(1).Info("Reconciling hosted DNS zone")
 
 	// Describe or create.
-	_, err := s.describeWorkloadClusterZone()
-	if IsNotFound(err) {
-		err = s.createWorkloadClusterZone()
+	var hostedZoneID string
+	err := s.traceStep(ctx, "DescribeWorkloadClusterZone", func(ctx context.Context) (err error) {
+		hostedZoneID, err = s.describeWorkloadClusterZone(ctx)
+		return err
+	})
+	zoneNotFound := IsNotFound(err)
+	if err != nil && !zoneNotFound {
+		return err
+	}
+
+	// the DNS mode of the cluster might have changed, so the zone might exist in the previous mode
+	if zoneNotFound || s.modeMigrationRequested() {
+		err = s.traceStep(ctx, "DescribePreviousModeZone", s.describePreviousModeZone)
 		if err != nil {
 			return err
 		}
-		s.scope.Logger().Info(fmt.Sprintf("Created new hosted zone for cluster %s", s.scope.Name()))
-	} else if err != nil {
+	}
+
+	if zoneNotFound {
+		s.setMigrationStep(key.CreatingZoneReason)
+		err = s.traceStep(ctx, "CreateWorkloadClusterZone", func(ctx context.Context) (err error) {
+			hostedZoneID, err = s.createWorkloadClusterZone(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		s.setWorkloadClusterZone(hostedZoneID)
+		s.logger().Info("Created hosted DNS zone")
+	}
+
+	err = s.traceStep(ctx, "ReconcileQueryLogging", func(ctx context.Context) error {
+		return s.reconcileQueryLogging(ctx, hostedZoneID)
+	})
+	if err != nil {
 		return err
 	}
 
-	err = s.changeWorkloadClusterRecords("CREATE")
-	if IsNotFound(err) {
-		// Fall through
+	s.setMigrationStep(key.CopyingRecordsReason)
+	err = s.traceStep(ctx, "ReconcileRecords", func(ctx context.Context) error {
+		return s.changeWorkloadClusterRecords(ctx, route53.ChangeActionUpsert)
+	})
+	if IsNotReady(err) && s.migration == nil {
+		// records are created once the API endpoint is known, a migration waits for them
 	} else if err != nil {
 		return errors.Wrap(err, "failed creating workload cluster DNS records")
 	}
 
+	s.setMigrationStep(key.SwitchingDelegationReason)
+	// signing has to be enabled before the DS record is published in the parent zone
+	if s.scope.DNSSEC() {
+		err = s.traceStep(ctx, "ReconcileDNSSEC", s.reconcileDNSSEC)
+		if err != nil {
+			return err
+		}
+	}
+
 	// delegation only make sense for public zones
 	if !s.scope.PrivateZone() {
-		err = s.changeManagementClusterDelegation("CREATE")
+		err = s.traceStep(ctx, "ReconcileDelegation", func(ctx context.Context) error {
+			return s.changeManagementClusterDelegation(ctx, route53.ChangeActionUpsert)
+		})
 		if IsNotFound(err) {
-			return nil
+			// parent zone doesn't exist, nothing to delegate
+		} else if err != nil {
+			return err
+		}
+
+		// signing of a zone the cluster turned DNSSEC off for is disabled once the removed DS record expired
+		err = s.traceStep(ctx, "DisableDNSSEC", s.disableDNSSEC)
+		if IsNotReady(err) {
+			// the zone serves its records in the meantime, signing is disabled by a later reconcile
+			s.logger().Info("Waiting to disable DNSSEC signing", "reason", err.Error())
 		} else if err != nil {
 			return err
 		}
 	}
 
+	// the zone of the previous DNS mode is deleted once the zone of the new mode serves the records
+	if s.migration != nil {
+		err = s.traceStep(ctx, "DeletePreviousModeZone", s.completeModeMigration)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (s *Service) describeWorkloadClusterZone() (string, error) {
-	// Search host zone by DNSName
-	input := &route53.ListHostedZonesByNameInput{
-		DNSName: aws.String(fmt.Sprintf("%s.%s", s.scope.Name(), s.scope.BaseDomain())),
+// describeWorkloadClusterZone returns the ID of the workload cluster zone. The zone is only looked up
+// by name when its ID isn't known from this or a previous reconcile, see hostedZoneCache.
+func (s *Service) describeWorkloadClusterZone(ctx context.Context) (string, error) {
+	if s.workloadZoneID != "" {
+		return s.workloadZoneID, nil
 	}
-	out, err := s.Route53Client.ListHostedZonesByName(input)
+
+	cachedID, err := s.cachedWorkloadClusterZone(ctx)
 	if err != nil {
 		return "", err
 	}
-	if len(out.HostedZones) == 0 {
-		return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+	if cachedID != "" {
+		s.setWorkloadClusterZone(cachedID)
+		return cachedID, nil
 	}
 
-	if *out.HostedZones[0].Name != fmt.Sprintf("%s.%s.", s.scope.Name(), s.scope.BaseDomain()) {
-		return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+	hostZoneID, err := s.describeWorkloadClusterZoneByName(ctx, s.scope.PrivateZone())
+	if err != nil {
+		return "", err
 	}
 
-	return *out.HostedZones[0].Id, nil
+	s.setWorkloadClusterZone(hostZoneID)
+	return hostZoneID, nil
 }
 
-func (s *Service) listWorkloadClusterNSRecords() ([]*route53.ResourceRecord, error) {
-	hostZoneID, err := s.describeWorkloadClusterZone()
+// describeWorkloadClusterZoneByName returns the ID of the workload cluster zone in the given DNS mode.
+// A public and a private zone with the same name can exist next to each other.
+func (s *Service) describeWorkloadClusterZoneByName(ctx context.Context, private bool) (string, error) {
+	input := &route53.ListHostedZonesByNameInput{
+		DNSName: aws.String(s.workloadClusterZoneName()),
+	}
+	out, err := s.Route53Client.ListHostedZonesByNameWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	// Zones are sorted by name, so we can stop at the first zone with a different name.
+	for _, zone := range out.HostedZones {
+		if aws.StringValue(zone.Name) != s.workloadClusterZoneName() {
+			break
+		}
+		if (zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone)) == private {
+			return aws.StringValue(zone.Id), nil
+		}
+	}
+
+	return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+}
+
+// listWorkloadClusterNSRecords returns the name servers of the workload cluster zone. They are taken from
+// the delegation set of the zone, or from its apex `NS` record if the zone has no delegation set.
+func (s *Service) listWorkloadClusterNSRecords(ctx context.Context) ([]*route53.ResourceRecord, error) {
+	hostZoneID, err := s.describeWorkloadClusterZone(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// First entry is always NS record
-	input := &route53.ListResourceRecordSetsInput{
-		HostedZoneId: aws.String(hostZoneID),
-		MaxItems:     aws.String("1"),
+	out, err := s.getWorkloadClusterZone(ctx, hostZoneID)
+	if err != nil {
+		return nil, err
+	}
+	if out.DelegationSet != nil && len(out.DelegationSet.NameServers) > 0 {
+		var records []*route53.ResourceRecord
+		for _, ns := range out.DelegationSet.NameServers {
+			records = append(records, &route53.ResourceRecord{Value: ns})
+		}
+		return records, nil
 	}
 
-	output, err := s.Route53Client.ListResourceRecordSets(input)
+	recordSet, err := describeResourceRecordSet(ctx, s.Route53Client, hostZoneID, fmt.Sprintf("%s.%s.", s.scope.Name(), s.scope.BaseDomain()), route53.RRTypeNs)
 	if err != nil {
 		return nil, err
 	}
-	return output.ResourceRecordSets[0].ResourceRecords, nil
+	if len(recordSet.ResourceRecords) == 0 {
+		return nil, NewNotFound(fmt.Sprintf("no name servers found for hosted zone %s", hostZoneID))
+	}
+
+	return recordSet.ResourceRecords, nil
 }
 
-// changeWorkloadClusterRecords creates the DNS records required by the workload cluster like
+// changeWorkloadClusterRecords creates the DNS records required by the workload cluster from the
+// record templates, by default
 // - a wildcard `CNAME` record pointing to the ingress record
-// - an `A` dns record 'api' pointing to the control plane LB
+// - an `A` (and `AAAA` for dual-stack clusters) dns record 'api' pointing to the control plane LB, see reconcileAPIRecords
 // - optionally an `A` dns record 'bastion1' pointing to the bastion machine IP
-func (s *Service) changeWorkloadClusterRecords(action string) error {
+// - optionally an `AAAA` dns record 'bastion1' pointing to the bastion machine IPv6 address
+// Only records which differ from the current records in the zone are changed. Records created from
+// templates which were removed, renamed or render an empty value by now are deleted.
+func (s *Service) changeWorkloadClusterRecords(ctx context.Context, action string) error {
 	if s.scope.APIEndpoint() == "" {
-		s.scope.Logger().Info("API endpoint is not ready yet.")
-		return aws.ErrMissingEndpoint
+		s.logger().Info("API endpoint is not ready yet")
+		return NewNotReady("API endpoint is not ready yet")
 	}
 
-	hostZoneID, err := s.describeWorkloadClusterZone()
+	hostZoneID, err := s.describeWorkloadClusterZone(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "failed describing workload cluster hosted zone")
 	}
 
-	changes := []*route53.Change{
-		{
-			Action: aws.String(action),
-			ResourceRecordSet: &route53.ResourceRecordSet{
-				Name: aws.String(fmt.Sprintf("*.%s.%s", s.scope.Name(), s.scope.BaseDomain())),
-				Type: aws.String("CNAME"),
-				TTL:  aws.Int64(300),
-				ResourceRecords: []*route53.ResourceRecord{
-					{
-						Value: aws.String(fmt.Sprintf("ingress.%s.%s", s.scope.Name(), s.scope.BaseDomain())),
-					},
-				},
-			},
-		},
-		{
-			Action: aws.String(action),
-			ResourceRecordSet: &route53.ResourceRecordSet{
-				Name: aws.String(fmt.Sprintf("api.%s.%s", s.scope.Name(), s.scope.BaseDomain())),
-				Type: aws.String("A"),
-				AliasTarget: &route53.AliasTarget{
-					DNSName:              aws.String(s.scope.APIEndpoint()),
-					EvaluateTargetHealth: aws.Bool(false),
-					HostedZoneId:         aws.String(canonicalHostedZones[s.scope.Region()]),
-				},
-			},
-		},
+	records, err := s.scope.RecordTemplates().RenderRecords(s.recordTemplateData())
+	if err != nil {
+		return err
 	}
 
-	input := &route53.ChangeResourceRecordSetsInput{
-		HostedZoneId: aws.String(hostZoneID),
-		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+	current, err := s.listWorkloadClusterRecords(ctx, hostZoneID)
+	if err != nil {
+		return err
 	}
+	s.recordCount, s.recordCountKnown = len(current), true
 
-	_, err = s.Route53Client.ChangeResourceRecordSets(input)
-	if IsAlreadyExists(err) {
-		// if record already exists, continue with bastion
-	} else if err != nil {
-		s.scope.Logger().Info("failed to change base DNS records", "error", err.Error())
-		return err
+	var changes []*route53.Change
+	managed := map[string]bool{}
+	for _, r := range records {
+		managed[managedRecord(r.Name, r.Type)] = true
+
+		desired := &route53.ResourceRecordSet{
+			Name: aws.String(r.Name),
+			Type: aws.String(r.Type),
+			TTL:  aws.Int64(r.TTL),
+		}
+		for _, v := range r.Values {
+			desired.ResourceRecords = append(desired.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+		}
+
+		if c := findRecordSet(current, r.Name, r.Type); c != nil &&
+			aws.Int64Value(c.TTL) == r.TTL && equalResourceRecords(c.ResourceRecords, desired.ResourceRecords) {
+			continue
+		}
+		changes = append(changes, &route53.Change{Action: aws.String(action), ResourceRecordSet: desired})
 	}
 
-	// bastion is optional and the operation is transactions so all must succeed
-	if s.scope.BastionIP() != "" {
-		changes := []*route53.Change{
-			{
-				Action: aws.String(action),
-				ResourceRecordSet: &route53.ResourceRecordSet{
-					Name: aws.String(fmt.Sprintf("bastion1.%s.%s", s.scope.Name(), s.scope.BaseDomain())),
-					Type: aws.String("A"),
-					TTL:  aws.Int64(300),
-					ResourceRecords: []*route53.ResourceRecord{
-						{
-							Value: aws.String(s.scope.BastionIP()),
-						},
-					},
-				},
-			},
+	// only records the operator created are deleted, other records in the zone are left alone
+	for _, previous := range s.scope.ManagedRecords() {
+		recordType, name, ok := strings.Cut(previous, " ")
+		if !ok || managed[managedRecord(name, recordType)] {
+			continue
 		}
+		if c := findRecordSet(current, name, recordType); c != nil {
+			changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: c})
+		}
+	}
 
+	if len(changes) > 0 {
 		input := &route53.ChangeResourceRecordSetsInput{
 			HostedZoneId: aws.String(hostZoneID),
 			ChangeBatch:  &route53.ChangeBatch{Changes: changes},
 		}
+		out, err := s.Route53Client.ChangeResourceRecordSetsWithContext(ctx, input)
+		if err != nil {
+			return errors.Wrap(err, "failed to change DNS records")
+		}
+		s.logger().Info("Changed DNS records", "changes", len(changes), "changeID", aws.StringValue(out.ChangeInfo.Id))
+		s.countRecordChanges(changes, current)
+		s.recordChangesEvent(changes, current)
+	}
 
-		_, err := s.Route53Client.ChangeResourceRecordSets(input)
-		if IsAlreadyExists(err) {
-			// update record
-			input.ChangeBatch.Changes[0].Action = aws.String("UPSERT")
-			_, err := s.Route53Client.ChangeResourceRecordSets(input)
-			if err != nil {
-				s.scope.Logger().Info("failed to update bastion DNS records", "error", err.Error())
-				return err
-			}
+	var managedRecords []string
+	for r := range managed {
+		managedRecords = append(managedRecords, r)
+	}
+	s.scope.SetManagedRecords(managedRecords)
 
-		} else if err != nil {
-			s.scope.Logger().Info("failed to change bastion DNS records", "error", err.Error())
-			return err
+	return s.reconcileAPIRecords(ctx, hostZoneID)
+}
+
+// managedRecord returns the entry of a record in the list of records created from the record templates.
+func managedRecord(name, recordType string) string {
+	return fmt.Sprintf("%s %s", recordType, normalizeRecordName(name))
+}
+
+// listWorkloadClusterRecords returns all record sets of the workload cluster zone.
+func (s *Service) listWorkloadClusterRecords(ctx context.Context, hostZoneID string) ([]*route53.ResourceRecordSet, error) {
+	var recordSets []*route53.ResourceRecordSet
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(hostZoneID)}
+	for {
+		out, err := s.Route53Client.ListResourceRecordSetsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
 		}
+		recordSets = append(recordSets, out.ResourceRecordSets...)
+
+		if !aws.BoolValue(out.IsTruncated) {
+			return recordSets, nil
+		}
+		input.StartRecordName = out.NextRecordName
+		input.StartRecordType = out.NextRecordType
+		input.StartRecordIdentifier = out.NextRecordIdentifier
 	}
+}
 
-	return nil
+// countRecordChanges updates the number of record sets in the workload cluster zone with the
+// submitted changes. current are the record sets before the changes.
+func (s *Service) countRecordChanges(changes []*route53.Change, current []*route53.ResourceRecordSet) {
+	for _, change := range changes {
+		switch {
+		case aws.StringValue(change.Action) == route53.ChangeActionDelete:
+			s.recordCount--
+		case findRecordSetWithIdentifier(current, change.ResourceRecordSet) == nil:
+			s.recordCount++
+		}
+	}
 }
 
-func (s *Service) deleteAllWorkloadClusterRecords(action string) error {
-	hostZoneID, err := s.describeWorkloadClusterZone()
-	if err != nil {
-		return err
+func (s *Service) recordTemplateData() records.Data {
+	return records.Data{
+		APIEndpoint: s.scope.APIEndpoint(),
+		BaseDomain:  s.scope.BaseDomain(),
+		BastionIP:   s.scope.BastionIP(),
+		BastionIPv6: s.scope.BastionIPv6(),
+		ClusterName: s.scope.Name(),
+	}
+}
+
+// findRecordSet returns the record set with the given name and type, nil if there is none.
+func findRecordSet(recordSets []*route53.ResourceRecordSet, name, recordType string) *route53.ResourceRecordSet {
+	for _, r := range recordSets {
+		if normalizeRecordName(aws.StringValue(r.Name)) == normalizeRecordName(name) && aws.StringValue(r.Type) == recordType {
+			return r
+		}
 	}
-	i := &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(hostZoneID)}
-	o, err := s.Route53Client.ListResourceRecordSets(i)
+	return nil
+}
 
+// normalizeRecordName makes record names comparable, Route53 returns `*` escaped as `\052`.
+func normalizeRecordName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSuffix(name, ".")), `\052`, "*")
+}
+
+// deleteAllWorkloadClusterRecords changes all record sets of the workload cluster zone except the
+// default SOA and NS records with the given action. All pages of the zone are listed and the changes
+// are submitted in chunks, so zones with many records can be deleted.
+func (s *Service) deleteAllWorkloadClusterRecords(ctx context.Context, hostZoneID, action string) error {
+	recordSets, err := s.listWorkloadClusterRecords(ctx, hostZoneID)
 	if err != nil {
-		s.scope.Logger().Error(err, "failed to list DNS records", "error", err.Error())
-		return err
+		return errors.Wrap(err, "failed to list DNS records")
 	}
 	var changes []*route53.Change
-	for _, r := range o.ResourceRecordSets {
+	for _, r := range recordSets {
 		// skip deletion of the undeletable default records
 		if *r.Type == "SOA" || *r.Type == "NS" {
 			continue
 		}
+		// the record set is passed as is, so routing policy and health check settings match
 		c := &route53.Change{
-			Action: aws.String(action),
-			ResourceRecordSet: &route53.ResourceRecordSet{
-				Name:            r.Name,
-				Type:            r.Type,
-				TTL:             r.TTL,
-				ResourceRecords: r.ResourceRecords,
-				AliasTarget:     r.AliasTarget,
-			},
+			Action:            aws.String(action),
+			ResourceRecordSet: r,
 		}
 		changes = append(changes, c)
 	}
 
-	input := &route53.ChangeResourceRecordSetsInput{
-		HostedZoneId: aws.String(hostZoneID),
-		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
-	}
 	if len(changes) == 0 {
 		// nothing to delete
 		return nil
 	}
 
-	_, err = s.Route53Client.ChangeResourceRecordSets(input)
-	if err != nil {
-		s.scope.Logger().Info("failed to delete DNS records", "error", err.Error())
-		return err
+	for start := 0; start < len(changes); start += maxChangesPerBatch {
+		end := start + maxChangesPerBatch
+		if end > len(changes) {
+			end = len(changes)
+		}
+		input := &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(hostZoneID),
+			ChangeBatch:  &route53.ChangeBatch{Changes: changes[start:end]},
+		}
+		out, err := s.Route53Client.ChangeResourceRecordSetsWithContext(ctx, input)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete DNS records")
+		}
+		s.logger().Info("Deleted DNS records", "changes", end-start, "changeID", aws.StringValue(out.ChangeInfo.Id))
 	}
+	record.Eventf(s.scope.InfraCluster(), eventRecordsDeleted, "Deleted %d DNS records of hosted zone %s", len(changes), s.workloadClusterZoneName())
 
 	return nil
 }
 
-func (s *Service) describeManagementClusterZone() (string, error) {
+// describeParentZone walks up the labels of the workload cluster zone name and
+// returns the ID and name of the closest public hosted zone in the delegation account.
+// The parent zone is cached like the workload cluster zone, see hostedZoneCache.
+func (s *Service) describeParentZone(ctx context.Context) (string, string, error) {
+	if s.parentZone != nil {
+		return s.parentZone.id, s.parentZone.name, nil
+	}
+	if cached, ok := hostedZoneCache.Load(s.parentZoneCacheKey()); ok {
+		s.parentZone = cached.(*parentZone)
+		return s.parentZone.id, s.parentZone.name, nil
+	}
+
+	labels := strings.Split(fmt.Sprintf("%s.%s", s.scope.Name(), s.scope.BaseDomain()), ".")
+	// Start with the direct parent, the workload cluster zone itself is never a candidate.
+	for i := 1; i < len(labels); i++ {
+		name := fmt.Sprintf("%s.", strings.Join(labels[i:], "."))
+		hostedZoneID, err := describePublicZone(ctx, s.ManagementRoute53Client, name)
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", "", err
+		}
+		s.parentZone = &parentZone{id: hostedZoneID, name: name}
+		hostedZoneCache.Store(s.parentZoneCacheKey(), s.parentZone)
+		return hostedZoneID, name, nil
+	}
+
+	return "", "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+}
+
+// describePublicZone returns the ID of the public hosted zone with the given fully qualified name.
+func describePublicZone(ctx context.Context, client route53iface.Route53API, name string) (string, error) {
 	input := &route53.ListHostedZonesByNameInput{
-		DNSName: aws.String(s.managementScope.BaseDomain()),
+		DNSName: aws.String(name),
 	}
-	out, err := s.ManagementRoute53Client.ListHostedZonesByName(input)
+	out, err := client.ListHostedZonesByNameWithContext(ctx, input)
 	if err != nil {
-		s.scope.Logger().Info(err.Error())
 		return "", err
 	}
-	if len(out.HostedZones) == 0 {
-		return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+
+	// Zones are sorted by name, so we can stop at the first zone with a different name.
+	for _, zone := range out.HostedZones {
+		if aws.StringValue(zone.Name) != name {
+			break
+		}
+		if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+			continue
+		}
+		return aws.StringValue(zone.Id), nil
+	}
+
+	return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+}
+
+// changeManagementClusterDelegation keeps the `NS` record delegating the workload cluster zone
+// in the closest parent zone in sync with the workload cluster zone name servers. When DNSSEC is
+// enabled, the `DS` record of the workload cluster zone key signing key is published as well.
+// With DELETE the records are removed using their current values.
+func (s *Service) changeManagementClusterDelegation(ctx context.Context, action string) error {
+	hostZoneID, parentZoneName, err := s.describeParentZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	recordName := fmt.Sprintf("%s.%s.", s.scope.Name(), s.scope.BaseDomain())
+
+	if action == route53.ChangeActionDelete {
+		s.logger().V(1).Info("Deleting delegation from parent zone", "parentZone", parentZoneName, "parentZoneID", hostZoneID)
+		// DS record can't exist without the NS record, so it is removed first
+		err = s.deleteDelegationRecord(ctx, hostZoneID, recordName, route53.RRTypeDs)
+		if err != nil {
+			return err
+		}
+		return s.deleteDelegationRecord(ctx, hostZoneID, recordName, route53.RRTypeNs)
+	}
+
+	records, err := s.listWorkloadClusterNSRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.syncDelegationRecord(ctx, hostZoneID, recordName, route53.RRTypeNs, records)
+	if err != nil {
+		return err
 	}
 
-	if *out.HostedZones[0].Name != fmt.Sprintf("%s.", s.managementScope.BaseDomain()) {
-		return "", &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeHostedZoneNotFound}
+	if !s.scope.DNSSEC() {
+		// DS record is only kept for signed zones
+		return s.deleteDelegationRecord(ctx, hostZoneID, recordName, route53.RRTypeDs)
+	}
+
+	dsRecord, err := s.describeDSRecord(ctx)
+	if err != nil {
+		return err
 	}
 
-	return *out.HostedZones[0].Id, nil
+	return s.syncDelegationRecord(ctx, hostZoneID, recordName, route53.RRTypeDs, []*route53.ResourceRecord{{Value: aws.String(dsRecord)}})
 }
 
-func (s *Service) changeManagementClusterDelegation(action string) error {
-	hostZoneID, err := s.describeManagementClusterZone()
+// syncDelegationRecord upserts the record in the parent zone if its values differ from the given records.
+func (s *Service) syncDelegationRecord(ctx context.Context, hostZoneID, recordName, recordType string, records []*route53.ResourceRecord) error {
+	current, err := describeResourceRecordSet(ctx, s.ManagementRoute53Client, hostZoneID, recordName, recordType)
+	if IsNotFound(err) {
+		current = nil
+	} else if err != nil {
+		return err
+	}
+
+	if current != nil && equalResourceRecords(current.ResourceRecords, records) {
+		// delegation is up to date
+		return nil
+	}
+	if current != nil {
+		s.delegationDrift++
+	}
+
+	desired := &route53.ResourceRecordSet{
+		Name:            aws.String(recordName),
+		Type:            aws.String(recordType),
+		TTL:             aws.Int64(s.scope.RecordTemplates().DelegationTTL()),
+		ResourceRecords: records,
+	}
+	err = s.changeDelegationRecord(ctx, hostZoneID, route53.ChangeActionUpsert, desired)
 	if err != nil {
 		return err
 	}
 
-	records, err := s.listWorkloadClusterNSRecords()
+	if current == nil {
+		record.Eventf(s.scope.InfraCluster(), eventDelegationChanged, "Created %s delegation record %s in parent zone %s with %s", recordType, recordName, hostZoneID, recordSetValues(desired))
+	} else {
+		record.Eventf(s.scope.InfraCluster(), eventDelegationChanged, "Updated %s delegation record %s in parent zone %s from %s to %s", recordType, recordName, hostZoneID, recordSetValues(current), recordSetValues(desired))
+	}
+	return nil
+}
+
+// deleteDelegationRecord deletes the record from the parent zone using its current values.
+func (s *Service) deleteDelegationRecord(ctx context.Context, hostZoneID, recordName, recordType string) error {
+	current, err := describeResourceRecordSet(ctx, s.ManagementRoute53Client, hostZoneID, recordName, recordType)
+	if IsNotFound(err) {
+		// nothing to delete
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = s.changeDelegationRecord(ctx, hostZoneID, route53.ChangeActionDelete, current)
 	if err != nil {
 		return err
 	}
+	if recordType == route53.RRTypeDs {
+		// resolvers may still validate the zone with the cached DS record until its TTL passed
+		s.scope.SetDNSSECDisableAfter(time.Now().Add(time.Duration(aws.Int64Value(current.TTL)) * time.Second))
+	}
 
+	record.Eventf(s.scope.InfraCluster(), eventDelegationDeleted, "Deleted %s delegation record %s from parent zone %s", recordType, recordName, hostZoneID)
+	return nil
+}
+
+// describeResourceRecordSet returns the record set with the given name and type in the hosted zone.
+func describeResourceRecordSet(ctx context.Context, client route53iface.Route53API, hostZoneID, recordName, recordType string) (*route53.ResourceRecordSet, error) {
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostZoneID),
+		StartRecordName: aws.String(recordName),
+		StartRecordType: aws.String(recordType),
+		MaxItems:        aws.String("1"),
+	}
+	out, err := client.ListResourceRecordSetsWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record listing starts at the given name, so the first record might belong to another name.
+	for _, r := range out.ResourceRecordSets {
+		if strings.EqualFold(aws.StringValue(r.Name), recordName) && aws.StringValue(r.Type) == recordType {
+			return r, nil
+		}
+	}
+
+	return nil, NewNotFound(fmt.Sprintf("%s record %s not found in hosted zone %s", recordType, recordName, hostZoneID))
+}
+
+func (s *Service) changeDelegationRecord(ctx context.Context, hostZoneID, action string, recordSet *route53.ResourceRecordSet) error {
 	input := &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: aws.String(hostZoneID),
 		ChangeBatch: &route53.ChangeBatch{
 			Changes: []*route53.Change{
 				{
-					Action: aws.String(action),
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name:            aws.String(fmt.Sprintf("%s.%s", s.scope.Name(), s.managementScope.BaseDomain())),
-						Type:            aws.String("NS"),
-						TTL:             aws.Int64(300),
-						ResourceRecords: records,
-					},
+					Action:            aws.String(action),
+					ResourceRecordSet: recordSet,
 				},
 			},
 		},
 	}
 
-	_, err = s.ManagementRoute53Client.ChangeResourceRecordSets(input)
+	out, err := s.ManagementRoute53Client.ChangeResourceRecordSetsWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
+	s.logger().Info("Changed delegation record", "action", action, "recordType", aws.StringValue(recordSet.Type), "parentZoneID", hostZoneID, "changeID", aws.StringValue(out.ChangeInfo.Id))
 
 	return nil
 }
 
-func (s *Service) createWorkloadClusterZone() error {
-	if s.scope.PrivateZone() && s.scope.VPC() == "" {
-		s.scope.Logger().Info("VPC ID is not ready yet for Private Hosted Zone")
-		return aws.ErrMissingEndpoint
+// equalResourceRecords returns true if both lists contain the same values regardless of order and case.
+func equalResourceRecords(a, b []*route53.ResourceRecord) bool {
+	if len(a) != len(b) {
+		return false
+	}
 
+	values := map[string]int{}
+	for _, r := range a {
+		values[strings.ToLower(strings.TrimSuffix(aws.StringValue(r.Value), "."))]++
+	}
+	for _, r := range b {
+		value := strings.ToLower(strings.TrimSuffix(aws.StringValue(r.Value), "."))
+		if values[value] == 0 {
+			return false
+		}
+		values[value]--
+	}
+
+	return true
+}
+
+// VerifyDelegation checks that the authoritative name servers of the parent zone delegate the
+// workload cluster zone to its current name servers and that the API record is resolvable.
+func (s *Service) VerifyDelegation(ctx context.Context, verifier *dnsverifier.Verifier) error {
+	return s.traceStep(ctx, "VerifyDelegation", func(ctx context.Context) error {
+		return s.verifyDelegation(ctx, verifier)
+	})
+}
+
+func (s *Service) verifyDelegation(ctx context.Context, verifier *dnsverifier.Verifier) error {
+	parentZoneID, _, err := s.describeParentZone(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := s.ManagementRoute53Client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(parentZoneID)})
+	if err != nil {
+		return err
+	}
+	if out.DelegationSet == nil {
+		return &Route53Error{Code: http.StatusNotFound, msg: route53.ErrCodeNoSuchDelegationSet}
+	}
+
+	records, err := s.listWorkloadClusterNSRecords(ctx)
+	if err != nil {
+		return err
+	}
+	var nameServers []string
+	for _, r := range records {
+		nameServers = append(nameServers, aws.StringValue(r.Value))
+	}
+
+	zoneName := fmt.Sprintf("%s.%s.", s.scope.Name(), s.scope.BaseDomain())
+	err = verifier.VerifyDelegation(ctx, zoneName, aws.StringValueSlice(out.DelegationSet.NameServers), nameServers)
+	if err != nil {
+		return err
+	}
+
+	if s.scope.APIEndpoint() == "" {
+		// API record is not created yet.
+		return nil
+	}
+
+	apiName, err := s.scope.RecordTemplates().APIName(s.recordTemplateData())
+	if err != nil {
+		return err
+	}
+
+	return verifier.VerifyRecord(ctx, apiName, nameServers)
+}
+
+func (s *Service) createWorkloadClusterZone(ctx context.Context) (string, error) {
+	if s.scope.PrivateZone() && s.scope.VPC() == "" {
+		return "", NewNotReady("VPC ID is not ready yet for private hosted zone")
 	}
 
 	now := time.Now()
@@ -342,9 +799,18 @@ func (s *Service) createWorkloadClusterZone() error {
 			VPCRegion: aws.String(s.scope.Region()),
 		}
 	}
-	o, err := s.Route53Client.CreateHostedZone(input)
+
+	delegationSetID, err := s.reconcileDelegationSet(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create hosted zone for cluster: %s", s.scope.Name())
+		return "", err
+	}
+	if delegationSetID != "" {
+		input.DelegationSetId = aws.String(delegationSetID)
+	}
+
+	o, err := s.Route53Client.CreateHostedZoneWithContext(ctx, input)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create hosted zone for cluster: %s", s.scope.Name())
 	}
 
 	if s.scope.PrivateZone() {
@@ -361,10 +827,11 @@ func (s *Service) createWorkloadClusterZone() error {
 					VPCRegion: aws.String(s.managementScope.Region()),
 				},
 			}
-			_, err := s.Route53Client.AssociateVPCWithHostedZone(i)
+			_, err := s.Route53Client.AssociateVPCWithHostedZoneWithContext(ctx, i)
 			if err != nil {
-				return errors.Wrapf(err, "failed to associate private hosted zone with vpc %s, for cluster %s", vpc, s.scope.Name())
+				return "", errors.Wrapf(err, "failed to associate private hosted zone with vpc %s, for cluster %s", vpc, s.scope.Name())
 			}
+			record.Eventf(s.scope.InfraCluster(), eventVPCAssociated, "Associated VPC %s with private hosted zone %s", vpc, aws.StringValue(o.HostedZone.Id))
 		}
 	}
 
@@ -387,21 +854,23 @@ func (s *Service) createWorkloadClusterZone() error {
 		ResourceId:   o.HostedZone.Id,
 		ResourceType: aws.String("hostedzone"),
 	}
-	_, err = s.Route53Client.ChangeTagsForResource(tagsInput)
+	_, err = s.Route53Client.ChangeTagsForResourceWithContext(ctx, tagsInput)
 	if err != nil {
-		return errors.Wrapf(err, "failed to add tags to hosted zone for cluster %s", s.scope.Name())
+		return "", errors.Wrapf(err, "failed to add tags to hosted zone for cluster %s", s.scope.Name())
 	}
 
-	return nil
+	record.Eventf(s.scope.InfraCluster(), eventHostedZoneCreated, "Created hosted zone %s (%s)", s.workloadClusterZoneName(), aws.StringValue(o.HostedZone.Id))
+	return aws.StringValue(o.HostedZone.Id), nil
 }
 
-func (s *Service) deleteWorkloadClusterZone(hostedZoneID string) error {
+func (s *Service) deleteWorkloadClusterZone(ctx context.Context, hostedZoneID string) error {
 	input := &route53.DeleteHostedZoneInput{
 		Id: aws.String(hostedZoneID),
 	}
-	_, err := s.Route53Client.DeleteHostedZone(input)
+	_, err := s.Route53Client.DeleteHostedZoneWithContext(ctx, input)
 	if err != nil {
 		return errors.Wrapf(err, "failed to delete hosted zone for cluster: %s", s.scope.Name())
 	}
+	record.Eventf(s.scope.InfraCluster(), eventHostedZoneDeleted, "Deleted hosted zone %s (%s)", s.workloadClusterZoneName(), hostedZoneID)
 	return nil
 }
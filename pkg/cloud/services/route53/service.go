@@ -1,8 +1,10 @@
 package route53
 
 import (
+	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/aws/aws-sdk-go/service/route53resolver/route53resolveriface"
+	"github.com/go-logr/logr"
 
 	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
 )
@@ -14,15 +16,68 @@ type Service struct {
 	Route53Client           route53iface.Route53API
 	Route53ResolverClient   route53resolveriface.Route53ResolverAPI
 	ManagementRoute53Client route53iface.Route53API
+
+	// hosted zones looked up during this reconcile
+	workloadZoneID string
+	workloadZone   *route53.GetHostedZoneOutput
+	parentZone     *parentZone
+
+	// operation is the reconcile step currently running, see traceStep
+	operation string
+
+	// migration of the zone to another DNS mode, nil if the zone of the previous mode doesn't exist
+	migration *modeMigration
+
+	// observations of this reconcile exposed for metrics
+	recordCount      int
+	recordCountKnown bool
+	delegationDrift  int
 }
 
 // NewService returns a new service given the route53 api client.
+// Only record changes of the parent zone in the management cluster account are batched, workload cluster
+// zones are changed by a single reconcile at a time and don't wait for a batch.
 func NewService(clusterScope scope.Route53Scope, managementScope scope.ManagementRoute53Scope) *Service {
 	return &Service{
 		scope:                   clusterScope,
 		managementScope:         managementScope,
-		Route53Client:           scope.NewRoute53Client(clusterScope, clusterScope.ARN(), clusterScope.InfraCluster()),
-		Route53ResolverClient:   scope.NewRoute53ResolverClient(clusterScope, clusterScope.ARN(), clusterScope.InfraCluster()),
-		ManagementRoute53Client: scope.NewRoute53Client(managementScope, managementScope.ARN(), managementScope.InfraCluster()),
+		Route53Client:           scope.NewRoute53Client(clusterScope, clusterScope.Credentials(), clusterScope.InfraCluster()),
+		Route53ResolverClient:   scope.NewRoute53ResolverClient(clusterScope, clusterScope.Credentials(), clusterScope.InfraCluster()),
+		ManagementRoute53Client: newBatchingClient(scope.NewRoute53Client(managementScope, managementScope.DelegationCredentials(), managementScope.InfraCluster())),
+	}
+}
+
+// logger returns the logger of the cluster scope with the current operation and the workload
+// cluster zone ID once they are known.
+func (s *Service) logger() logr.Logger {
+	log := s.scope.Logger()
+	if s.operation != "" {
+		log = log.WithValues("operation", s.operation)
+	}
+	if s.workloadZoneID != "" {
+		log = log.WithValues("zoneID", s.workloadZoneID)
+	}
+	return log
+}
+
+// RecordCount returns the number of record sets in the workload cluster zone after the reconcile,
+// false if the zone records were not listed.
+func (s *Service) RecordCount() (int, bool) {
+	return s.recordCount, s.recordCountKnown
+}
+
+// DelegationDrift returns the number of delegation records in the parent zone which had to be
+// corrected because they didn't match the workload cluster zone.
+func (s *Service) DelegationDrift() int {
+	return s.delegationDrift
+}
+
+// ModeMigration returns the reason of the DNSModeMigrated condition for the step of the DNS mode
+// migration reached by the reconcile, empty if the cluster is not migrated, and whether the
+// migration completed.
+func (s *Service) ModeMigration() (string, bool) {
+	if s.migration == nil {
+		return "", false
 	}
+	return s.migration.step, s.migration.completed
 }
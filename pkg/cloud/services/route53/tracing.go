@@ -0,0 +1,32 @@
+package route53
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/tracing"
+)
+
+// traceStep runs a step of the reconcile in a child span of the span in ctx, logs of the step carry
+// its name as operation. Missing resources and infrastructure which isn't ready yet are expected and
+// not reported as span errors.
+func (s *Service) traceStep(ctx context.Context, name string, step func(context.Context) error) error {
+	ctx, span := tracing.StartSpan(ctx, "route53."+name, attribute.String("cluster", s.scope.Name()))
+	parentOperation := s.operation
+	s.operation = name
+	err := step(ctx)
+	s.operation = parentOperation
+
+	spanErr := err
+	if IsNotFound(err) || IsNotReady(err) {
+		span.SetAttributes(attribute.String("result", err.Error()))
+		spanErr = nil
+	}
+	if s.workloadZoneID != "" {
+		span.SetAttributes(attribute.String("zone_id", s.workloadZoneID))
+	}
+	tracing.EndSpan(span, spanErr)
+
+	return err
+}
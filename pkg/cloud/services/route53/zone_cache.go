@@ -0,0 +1,110 @@
+package route53
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/awserrors"
+)
+
+// hostedZoneCache holds the IDs of the hosted zones looked up by previous reconciles, so steady state
+// reconciles don't list hosted zones by name. Workload cluster zones are keyed by the UID of the
+// infrastructure cluster, the DNS mode and the zone name, parent zones by the workload cluster zone name.
+// Entries are removed when Route53 reports the zone as missing.
+var hostedZoneCache sync.Map
+
+type parentZone struct {
+	id   string
+	name string
+}
+
+func (s *Service) workloadClusterZoneName() string {
+	return fmt.Sprintf("%s.%s.", s.scope.Name(), s.scope.BaseDomain())
+}
+
+// workloadClusterZoneCacheKey includes the DNS mode, zones of both modes exist while a cluster is migrated.
+func (s *Service) workloadClusterZoneCacheKey() string {
+	return fmt.Sprintf("workload/%s/%s/%s", s.scope.InfraCluster().GetUID(), zoneMode(s.scope.PrivateZone()), s.workloadClusterZoneName())
+}
+
+func (s *Service) parentZoneCacheKey() string {
+	return fmt.Sprintf("parent/%s", s.workloadClusterZoneName())
+}
+
+// cachedWorkloadClusterZone returns the workload cluster zone ID known from previous reconciles or
+// persisted on the infrastructure cluster. A persisted ID is only used when it still belongs to the
+// workload cluster zone, e.g. it might be copied along with the infrastructure cluster.
+func (s *Service) cachedWorkloadClusterZone(ctx context.Context) (string, error) {
+	if id, ok := hostedZoneCache.Load(s.workloadClusterZoneCacheKey()); ok {
+		return id.(string), nil
+	}
+
+	id := s.scope.HostedZoneID()
+	if id == "" {
+		return "", nil
+	}
+	out, err := s.getWorkloadClusterZone(ctx, id)
+	if isNoSuchHostedZone(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if aws.StringValue(out.HostedZone.Name) != s.workloadClusterZoneName() ||
+		out.HostedZone.Config == nil || aws.BoolValue(out.HostedZone.Config.PrivateZone) != s.scope.PrivateZone() {
+		s.workloadZone = nil
+		return "", nil
+	}
+
+	hostedZoneCache.Store(s.workloadClusterZoneCacheKey(), id)
+	return id, nil
+}
+
+// getWorkloadClusterZone returns the workload cluster zone, it is only requested once per reconcile.
+func (s *Service) getWorkloadClusterZone(ctx context.Context, hostZoneID string) (*route53.GetHostedZoneOutput, error) {
+	if s.workloadZone != nil && aws.StringValue(s.workloadZone.HostedZone.Id) == hostZoneID {
+		return s.workloadZone, nil
+	}
+
+	out, err := s.Route53Client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: aws.String(hostZoneID)})
+	if err != nil {
+		return nil, err
+	}
+	s.workloadZone = out
+	return out, nil
+}
+
+// setWorkloadClusterZone remembers the workload cluster zone ID for this and future reconciles.
+func (s *Service) setWorkloadClusterZone(hostZoneID string) {
+	s.workloadZoneID = hostZoneID
+	hostedZoneCache.Store(s.workloadClusterZoneCacheKey(), hostZoneID)
+	s.scope.SetHostedZoneID(hostZoneID)
+}
+
+// forgetHostedZones removes the cached zone IDs when Route53 reports a zone as missing, so the
+// zones are looked up by name again on the next reconcile.
+func (s *Service) forgetHostedZones(err error) {
+	if !isNoSuchHostedZone(err) {
+		return
+	}
+	s.forgetWorkloadClusterZone()
+	s.parentZone = nil
+	hostedZoneCache.Delete(s.parentZoneCacheKey())
+}
+
+func (s *Service) forgetWorkloadClusterZone() {
+	s.workloadZoneID = ""
+	s.workloadZone = nil
+	hostedZoneCache.Delete(s.workloadClusterZoneCacheKey())
+	s.scope.SetHostedZoneID("")
+}
+
+// isNoSuchHostedZone returns true if the error is caused by a request for a hosted zone ID which doesn't exist.
+func isNoSuchHostedZone(err error) bool {
+	code, ok := awserrors.Code(errors.Cause(err))
+	return ok && code == route53.ErrCodeNoSuchHostedZone
+}
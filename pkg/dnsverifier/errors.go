@@ -0,0 +1,28 @@
+package dnsverifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var _ error = &MismatchError{}
+
+// MismatchError is returned when the public DNS does not serve the expected records.
+type MismatchError struct {
+	Name     string
+	Expected []string
+	Actual   []string
+}
+
+// Error implements the Error interface.
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("DNS mismatch for %s: expected [%s], got [%s]", e.Name, strings.Join(e.Expected, ","), strings.Join(e.Actual, ","))
+}
+
+// IsMismatch returns true if the error is a MismatchError.
+func IsMismatch(err error) bool {
+	_, ok := errors.Cause(err).(*MismatchError)
+	return ok
+}
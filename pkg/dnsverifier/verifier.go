@@ -0,0 +1,287 @@
+package dnsverifier
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	defaultNameserverPort = "53"
+	defaultTimeout        = 5 * time.Second
+	maxUDPMessageSize     = 4096
+)
+
+// Verifier checks DNS delegations by querying the authoritative name servers of the
+// parent zone directly instead of relying on a caching resolver.
+type Verifier struct {
+	// nameserverPort is the port used to query authoritative name servers.
+	nameserverPort string
+	// resolver resolves name server host names to addresses.
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+// Config defines the input parameters used to create a new Verifier.
+type Config struct {
+	// NameserverPort is the port used to query authoritative name servers. Defaults to 53.
+	NameserverPort string
+	// Resolvers are the addresses (host:port) of the recursive resolvers used to look up
+	// name server addresses. The system resolver is used when empty.
+	Resolvers []string
+	// Timeout is the timeout of a single DNS query. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// New creates a new Verifier from the supplied config.
+func New(config Config) *Verifier {
+	v := &Verifier{
+		nameserverPort: config.NameserverPort,
+		resolver:       net.DefaultResolver,
+		timeout:        config.Timeout,
+	}
+	if v.nameserverPort == "" {
+		v.nameserverPort = defaultNameserverPort
+	}
+	if v.timeout == 0 {
+		v.timeout = defaultTimeout
+	}
+
+	if len(config.Resolvers) > 0 {
+		resolvers := config.Resolvers
+		v.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: v.timeout}
+				return d.DialContext(ctx, network, resolvers[rand.Intn(len(resolvers))]) //nolint:gosec
+			},
+		}
+	}
+
+	return v
+}
+
+// VerifyDelegation queries the parent zone name servers for the NS set of the zone and
+// compares it with the expected name servers.
+func (v *Verifier) VerifyDelegation(ctx context.Context, zone string, parentNameServers, expectedNameServers []string) error {
+	msg, err := v.queryAny(ctx, parentNameServers, zone, dnsmessage.TypeNS, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query NS records of zone %s", zone)
+	}
+
+	// The parent answers with a referral, so the NS set is usually in the authority section.
+	var actual []string
+	for _, r := range append(msg.Answers, msg.Authorities...) {
+		ns, ok := r.Body.(*dnsmessage.NSResource)
+		if !ok || !equalNames(r.Header.Name.String(), zone) {
+			continue
+		}
+		actual = append(actual, ns.NS.String())
+	}
+
+	expected := normalizeNames(expectedNameServers)
+	actual = normalizeNames(actual)
+	if strings.Join(expected, ",") != strings.Join(actual, ",") {
+		return &MismatchError{Name: zone, Expected: expected, Actual: actual}
+	}
+
+	return nil
+}
+
+// VerifyRecord queries the zone name servers for the A record of the given name and
+// returns an error if it can not be resolved.
+func (v *Verifier) VerifyRecord(ctx context.Context, name string, nameServers []string) error {
+	msg, err := v.queryAny(ctx, nameServers, name, dnsmessage.TypeA, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query A record %s", name)
+	}
+
+	for _, r := range msg.Answers {
+		switch r.Body.(type) {
+		case *dnsmessage.AResource, *dnsmessage.CNAMEResource:
+			return nil
+		}
+	}
+
+	return &MismatchError{Name: name, Expected: []string{"A"}}
+}
+
+// queryAny sends the query to the given name servers one after another and returns the
+// first authoritative response. With acceptReferral a referral to the queried zone is accepted
+// as well, parent zone name servers don't answer authoritatively for the NS set of a child zone.
+func (v *Verifier) queryAny(ctx context.Context, nameServers []string, name string, qtype dnsmessage.Type, acceptReferral bool) (*dnsmessage.Message, error) {
+	if len(nameServers) == 0 {
+		return nil, errors.New("no name servers to query")
+	}
+
+	var lastErr error
+	for _, ns := range nameServers {
+		addrs, err := v.lookupHost(ctx, ns)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, addr := range addrs {
+			server := net.JoinHostPort(addr, v.nameserverPort)
+			msg, err := v.query(ctx, server, name, qtype)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if !msg.Authoritative && !(acceptReferral && isReferral(msg, name)) {
+				lastErr = errors.Errorf("non-authoritative response from %s for %s %s", server, qtype, name)
+				continue
+			}
+			return msg, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (v *Verifier) lookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	addrs, err := v.resolver.LookupHost(ctx, strings.TrimSuffix(host, "."))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve name server %s", host)
+	}
+	return addrs, nil
+}
+
+// query sends a non-recursive query over UDP and retries over TCP if the response is truncated.
+func (v *Verifier) query(ctx context.Context, server, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Intn(1 << 16))}, //nolint:gosec
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := req.Pack()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	resp, err := v.exchange(ctx, "udp", server, packed)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		resp, err = v.exchange(ctx, "tcp", server, packed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.ID != req.ID || !resp.Response {
+		return nil, errors.Errorf("unexpected response id from %s", server)
+	}
+	if len(resp.Questions) != 1 || !equalNames(resp.Questions[0].Name.String(), name) || resp.Questions[0].Type != qtype {
+		return nil, errors.Errorf("response from %s does not match the query %s %s", server, qtype, name)
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return nil, errors.Errorf("query %s %s to %s failed with %s", qtype, name, server, resp.RCode)
+	}
+
+	return resp, nil
+}
+
+func (v *Verifier) exchange(ctx context.Context, network, server string, packed []byte) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var buf []byte
+	if network == "tcp" {
+		// TCP messages are prefixed with their length.
+		length := make([]byte, 2)
+		binary.BigEndian.PutUint16(length, uint16(len(packed)))
+		if _, err := conn.Write(append(length, packed...)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf = make([]byte, maxUDPMessageSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf = buf[:n]
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse response from %s", server)
+	}
+	return &msg, nil
+}
+
+// isReferral returns true if the response delegates the name with NS records in the authority section.
+func isReferral(msg *dnsmessage.Message, name string) bool {
+	if len(msg.Answers) > 0 {
+		return false
+	}
+	for _, r := range msg.Authorities {
+		if _, ok := r.Body.(*dnsmessage.NSResource); ok && equalNames(r.Header.Name.String(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func equalNames(a, b string) bool {
+	return strings.EqualFold(fqdn(a), fqdn(b))
+}
+
+func normalizeNames(names []string) []string {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, strings.ToLower(fqdn(name)))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
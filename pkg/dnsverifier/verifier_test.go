@@ -0,0 +1,322 @@
+package dnsverifier
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	testZone    = "test.example.com."
+	testZoneNS1 = "ns-1.awsdns-01.org."
+	testZoneNS2 = "ns-2.awsdns-02.com."
+)
+
+// handler answers a query of the test server, udp is true for queries received over UDP.
+type handler func(req dnsmessage.Message, udp bool) dnsmessage.Message
+
+// startServer starts a DNS server on the address serving UDP and TCP on the same port and returns the port.
+func startServer(t *testing.T, ip string, h handler) string {
+	t.Helper()
+
+	var udp net.PacketConn
+	var tcp net.Listener
+	for i := 0; ; i++ {
+		var err error
+		udp, err = net.ListenPacket("udp", net.JoinHostPort(ip, "0"))
+		if err != nil {
+			t.Fatalf("failed to listen on udp: %v", err)
+		}
+		port := strconv.Itoa(udp.LocalAddr().(*net.UDPAddr).Port)
+		tcp, err = net.Listen("tcp", net.JoinHostPort(ip, port))
+		if err == nil {
+			break
+		}
+		udp.Close()
+		if i > 10 {
+			t.Fatalf("failed to listen on tcp: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go func() {
+		buf := make([]byte, maxUDPMessageSize)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp, ok := respond(h, buf[:n], true); ok {
+				_, _ = udp.WriteTo(resp, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				length := make([]byte, 2)
+				if _, err := io.ReadFull(conn, length); err != nil {
+					return
+				}
+				buf := make([]byte, binary.BigEndian.Uint16(length))
+				if _, err := io.ReadFull(conn, buf); err != nil {
+					return
+				}
+				resp, ok := respond(h, buf, false)
+				if !ok {
+					return
+				}
+				binary.BigEndian.PutUint16(length, uint16(len(resp)))
+				_, _ = conn.Write(append(length, resp...))
+			}()
+		}
+	}()
+
+	return strconv.Itoa(udp.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func respond(h handler, packed []byte, udp bool) ([]byte, bool) {
+	var req dnsmessage.Message
+	if err := req.Unpack(packed); err != nil {
+		return nil, false
+	}
+	resp := h(req, udp)
+	out, err := resp.Pack()
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// reply returns a response to the request with the header flags of the given header.
+func reply(req dnsmessage.Message, header dnsmessage.Header) dnsmessage.Message {
+	header.ID = req.ID
+	header.Response = true
+	return dnsmessage.Message{Header: header, Questions: req.Questions}
+}
+
+func nsResource(t *testing.T, owner, ns string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(t, owner), Type: dnsmessage.TypeNS, Class: dnsmessage.ClassINET, TTL: 300},
+		Body:   &dnsmessage.NSResource{NS: mustName(t, ns)},
+	}
+}
+
+func aResource(t *testing.T, owner string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(t, owner), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}},
+	}
+}
+
+func mustName(t *testing.T, name string) dnsmessage.Name {
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		t.Fatalf("invalid name %s: %v", name, err)
+	}
+	return n
+}
+
+// referral answers NS queries like a parent zone name server.
+func referral(t *testing.T, nameServers ...string) handler {
+	return func(req dnsmessage.Message, _ bool) dnsmessage.Message {
+		resp := reply(req, dnsmessage.Header{})
+		for _, ns := range nameServers {
+			resp.Authorities = append(resp.Authorities, nsResource(t, testZone, ns))
+		}
+		return resp
+	}
+}
+
+func newTestVerifier(port string) *Verifier {
+	return New(Config{NameserverPort: port, Timeout: time.Second})
+}
+
+func TestVerifyDelegation(t *testing.T) {
+	testCases := []struct {
+		name         string
+		nameServers  []string
+		wantMismatch bool
+	}{
+		{
+			name:        "referral matches in any order and case",
+			nameServers: []string{"NS-2.AWSDNS-02.com.", testZoneNS1},
+		},
+		{
+			name:         "referral to other name servers",
+			nameServers:  []string{testZoneNS1, "ns-3.awsdns-03.net."},
+			wantMismatch: true,
+		},
+		{
+			name:         "missing name server",
+			nameServers:  []string{testZoneNS1},
+			wantMismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			port := startServer(t, "127.0.0.1", referral(t, tc.nameServers...))
+			v := newTestVerifier(port)
+
+			err := v.VerifyDelegation(context.Background(), testZone, []string{"127.0.0.1"}, []string{testZoneNS1, testZoneNS2})
+			if tc.wantMismatch {
+				if !IsMismatch(err) {
+					t.Fatalf("expected mismatch, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRecordRequiresAuthoritativeAnswer(t *testing.T) {
+	apiName := "api." + testZone
+	answer := func(authoritative bool) handler {
+		return func(req dnsmessage.Message, _ bool) dnsmessage.Message {
+			resp := reply(req, dnsmessage.Header{Authoritative: authoritative})
+			resp.Answers = append(resp.Answers, aResource(t, apiName))
+			return resp
+		}
+	}
+
+	port := startServer(t, "127.0.0.1", answer(false))
+	v := newTestVerifier(port)
+	err := v.VerifyRecord(context.Background(), apiName, []string{"127.0.0.1"})
+	if err == nil {
+		t.Fatal("expected error for non-authoritative answer")
+	}
+
+	port = startServer(t, "127.0.0.1", answer(true))
+	v = newTestVerifier(port)
+	err = v.VerifyRecord(context.Background(), apiName, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyRecordMissing(t *testing.T) {
+	port := startServer(t, "127.0.0.1", func(req dnsmessage.Message, _ bool) dnsmessage.Message {
+		return reply(req, dnsmessage.Header{Authoritative: true})
+	})
+	v := newTestVerifier(port)
+
+	err := v.VerifyRecord(context.Background(), "api."+testZone, []string{"127.0.0.1"})
+	if !IsMismatch(err) {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+}
+
+func TestQueryFallsBackToTCPWhenTruncated(t *testing.T) {
+	var tcpQueries int32
+	port := startServer(t, "127.0.0.1", func(req dnsmessage.Message, udp bool) dnsmessage.Message {
+		if udp {
+			return reply(req, dnsmessage.Header{Truncated: true})
+		}
+		atomic.AddInt32(&tcpQueries, 1)
+		return referral(t, testZoneNS1, testZoneNS2)(req, udp)
+	})
+	v := newTestVerifier(port)
+
+	err := v.VerifyDelegation(context.Background(), testZone, []string{"127.0.0.1"}, []string{testZoneNS1, testZoneNS2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&tcpQueries); n != 1 {
+		t.Fatalf("expected 1 TCP query, got %d", n)
+	}
+}
+
+func TestQueryRejectsUnmatchedResponses(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(resp *dnsmessage.Message)
+	}{
+		{
+			name:   "other id",
+			modify: func(resp *dnsmessage.Message) { resp.ID++ },
+		},
+		{
+			name: "other question",
+			modify: func(resp *dnsmessage.Message) {
+				resp.Questions[0].Name = mustName(t, "other.example.com.")
+			},
+		},
+		{
+			name:   "failed query",
+			modify: func(resp *dnsmessage.Message) { resp.RCode = dnsmessage.RCodeServerFailure },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			port := startServer(t, "127.0.0.1", func(req dnsmessage.Message, udp bool) dnsmessage.Message {
+				resp := referral(t, testZoneNS1, testZoneNS2)(req, udp)
+				tc.modify(&resp)
+				return resp
+			})
+			v := newTestVerifier(port)
+
+			err := v.VerifyDelegation(context.Background(), testZone, []string{"127.0.0.1"}, []string{testZoneNS1, testZoneNS2})
+			if err == nil || IsMismatch(err) {
+				t.Fatalf("expected query error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryAnyTriesNextNameServer(t *testing.T) {
+	// the first name server doesn't answer authoritatively, the second one does
+	port := startServer(t, "127.0.0.1", func(req dnsmessage.Message, _ bool) dnsmessage.Message {
+		return reply(req, dnsmessage.Header{RecursionAvailable: true})
+	})
+	startServerOnPort(t, "127.0.0.2", port, referral(t, testZoneNS1, testZoneNS2))
+	v := newTestVerifier(port)
+
+	err := v.VerifyDelegation(context.Background(), testZone, []string{"127.0.0.1", "127.0.0.2"}, []string{testZoneNS1, testZoneNS2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// startServerOnPort starts a UDP only DNS server, skipping the test if the address can't be used.
+func startServerOnPort(t *testing.T, ip, port string, h handler) {
+	t.Helper()
+
+	udp, err := net.ListenPacket("udp", net.JoinHostPort(ip, port))
+	if err != nil {
+		t.Skipf("failed to listen on %s: %v", ip, err)
+	}
+	t.Cleanup(func() { udp.Close() })
+
+	go func() {
+		buf := make([]byte, maxUDPMessageSize)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp, ok := respond(h, buf[:n], true); ok {
+				_, _ = udp.WriteTo(resp, addr)
+			}
+		}
+	}()
+}
@@ -5,6 +5,78 @@ import (
 )
 
 const (
-	DNSFinalizerName                    = "dns-operator-aws.finalizers.giantswarm.io"
-	DNSZoneReady     capi.ConditionType = "DNSZoneReady"
+	DNSFinalizerName                         = "dns-operator-aws.finalizers.giantswarm.io"
+	DNSZoneReady          capi.ConditionType = "DNSZoneReady"
+	DNSDelegationVerified capi.ConditionType = "DNSDelegationVerified"
+	DNSModeMigrated       capi.ConditionType = "DNSModeMigrated"
+)
+
+const (
+	// APIHealthCheckAnnotation enables Route53 health checks for the routed `api` records.
+	APIHealthCheckAnnotation = "aws.giantswarm.io/dns-api-health-check"
+	// APIRoutingPolicyAnnotation sets the routing policy of the `api` record between the primary and secondary endpoint.
+	APIRoutingPolicyAnnotation = "aws.giantswarm.io/dns-api-routing-policy"
+	// APISecondaryEndpointAnnotation is the host name of the secondary control plane load balancer.
+	APISecondaryEndpointAnnotation = "aws.giantswarm.io/dns-api-secondary-endpoint"
+
+	APIRoutingPolicyFailover = "failover"
+	APIRoutingPolicyWeighted = "weighted"
+)
+
+const (
+	// DelegationSetIDAnnotation references an existing reusable delegation set used for the workload cluster zone.
+	DelegationSetIDAnnotation = "aws.giantswarm.io/dns-delegation-set-id"
+
+	// HostedZoneIDAnnotation holds the ID of the workload cluster hosted zone, it is set by the operator.
+	HostedZoneIDAnnotation = "aws.giantswarm.io/dns-hosted-zone-id"
+	// ManagedRecordsAnnotation lists the records created from the record templates as `<type> <name>`, it is
+	// set by the operator, so records of removed or renamed templates can be deleted.
+	ManagedRecordsAnnotation = "aws.giantswarm.io/dns-managed-records"
+	// ZoneFirstReadyAnnotation holds the time the workload cluster zone became ready for the first time,
+	// it is set by the operator, so the time to DNS ready is only observed once per cluster.
+	ZoneFirstReadyAnnotation = "aws.giantswarm.io/dns-zone-first-ready"
+
+	// DualStackAnnotation enables `AAAA` records for the dual-stack load balancers and the bastion machine.
+	DualStackAnnotation = "aws.giantswarm.io/dns-dual-stack"
+
+	// DNSSECAnnotation enables or disables DNSSEC signing of the public workload cluster zone.
+	DNSSECAnnotation = "aws.giantswarm.io/dns-dnssec"
+	// DNSSECDisableAfterAnnotation holds the time after which DNSSEC signing of the workload cluster zone
+	// can be disabled, it is set by the operator once the DS record is removed from the parent zone.
+	DNSSECDisableAfterAnnotation = "aws.giantswarm.io/dns-dnssec-disable-after"
+	// DNSSECKeySigningKeyName is the name of the key signing key created by the operator.
+	DNSSECKeySigningKeyName = "dns_operator_aws"
+
+	// DNSModePublic is the default value of the aws.giantswarm.io/dns-mode annotation, next to the private mode.
+	DNSModePublic = "public"
+	// DNSModeMigrationAnnotation allows changing the DNS mode of an existing cluster. The zone of the
+	// previous mode is replaced by a zone of the new mode.
+	DNSModeMigrationAnnotation = "aws.giantswarm.io/dns-mode-migration"
+
+	DelegationSetModeCluster      = "cluster"
+	DelegationSetModeInstallation = "installation"
+)
+
+const (
+	DelegationMismatchReason           = "DelegationMismatch"
+	DelegationVerificationFailedReason = "DelegationVerificationFailed"
+)
+
+// Reasons of the DNSZoneReady condition when reconciling the hosted zone failed.
+const (
+	AWSThrottledReason             = "AWSThrottled"
+	AWSAccessDeniedReason          = "AWSAccessDenied"
+	InvalidConfigurationReason     = "InvalidConfiguration"
+	WaitingForInfrastructureReason = "WaitingForInfrastructure"
+	ConflictReason                 = "Conflict"
+	ReconcileFailedReason          = "ReconcileFailed"
+)
+
+// Reasons of the DNSModeMigrated condition while the zone is migrated to another DNS mode.
+const (
+	MigrationNotAllowedReason  = "MigrationNotAllowed"
+	CreatingZoneReason         = "CreatingZone"
+	CopyingRecordsReason       = "CopyingRecords"
+	SwitchingDelegationReason  = "SwitchingDelegation"
+	DeletingPreviousZoneReason = "DeletingPreviousZone"
 )
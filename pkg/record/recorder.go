@@ -18,7 +18,8 @@ var (
 
 func init() {
 	defaultRecorder = new(record.FakeRecorder)
-	caser = cases.Title(language.English)
+	// reasons are CamelCase already, so only the first letter is changed
+	caser = cases.Title(language.English, cases.NoLower)
 }
 
 // InitFromRecorder initializes the global default recorder. It can only be called once.
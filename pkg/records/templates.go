@@ -0,0 +1,276 @@
+package records
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultTTL = 300
+
+var supportedTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"MX":    true,
+	"SRV":   true,
+	"TXT":   true,
+}
+
+// Data holds the values which can be used in record templates.
+type Data struct {
+	// APIEndpoint is the host name of the control plane load balancer.
+	APIEndpoint string
+	// BaseDomain is the workload cluster base domain.
+	BaseDomain string
+	// BastionIP is the IP of the bastion machine, empty if there is none.
+	BastionIP string
+	// BastionIPv6 is the IPv6 address of the bastion machine, empty if there is none or the cluster is not dual-stack.
+	BastionIPv6 string
+	// ClusterName is the workload cluster name.
+	ClusterName string
+}
+
+// Templates describes the records created by the operator.
+type Templates struct {
+	// API describes the `api` alias record pointing to the control plane load balancer.
+	API APITemplate `json:"api"`
+	// Delegation describes the `NS` and `DS` records in the parent zone.
+	Delegation DelegationTemplate `json:"delegation"`
+	// Records are the records created in the workload cluster zone.
+	// Records with an empty name or value after rendering are skipped.
+	Records []RecordTemplate `json:"records"`
+}
+
+// APITemplate describes the `api` alias record.
+type APITemplate struct {
+	Name string `json:"name"`
+	// TTL is used for the `api` CNAME record of EKS clusters, alias records take the TTL of their target.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// DelegationTemplate describes the delegation records in the parent zone.
+type DelegationTemplate struct {
+	TTL int64 `json:"ttl"`
+}
+
+// RecordTemplate describes a record in the workload cluster zone.
+type RecordTemplate struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	TTL    int64    `json:"ttl"`
+	Values []string `json:"values"`
+}
+
+// Record is a rendered RecordTemplate.
+type Record struct {
+	Name   string
+	Type   string
+	TTL    int64
+	Values []string
+}
+
+// DefaultTemplates returns the templates used when no templates file is configured.
+func DefaultTemplates() *Templates {
+	return &Templates{
+		API: APITemplate{
+			Name: "api.{{ .ClusterName }}.{{ .BaseDomain }}",
+			TTL:  defaultTTL,
+		},
+		Delegation: DelegationTemplate{
+			TTL: defaultTTL,
+		},
+		Records: []RecordTemplate{
+			{
+				Name:   "*.{{ .ClusterName }}.{{ .BaseDomain }}",
+				Type:   "CNAME",
+				TTL:    defaultTTL,
+				Values: []string{"ingress.{{ .ClusterName }}.{{ .BaseDomain }}"},
+			},
+			{
+				Name:   "bastion1.{{ .ClusterName }}.{{ .BaseDomain }}",
+				Type:   "A",
+				TTL:    defaultTTL,
+				Values: []string{"{{ .BastionIP }}"},
+			},
+			{
+				Name:   "bastion1.{{ .ClusterName }}.{{ .BaseDomain }}",
+				Type:   "AAAA",
+				TTL:    defaultTTL,
+				Values: []string{"{{ .BastionIPv6 }}"},
+			},
+		},
+	}
+}
+
+// LoadTemplates reads and validates the templates from a YAML file.
+func LoadTemplates(path string) (*Templates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read record templates file %s", path)
+	}
+
+	var t Templates
+	err = yaml.UnmarshalStrict(data, &t)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse record templates file %s", path)
+	}
+
+	err = t.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Validate checks that all templates can be rendered and result in records of the workload cluster zone.
+func (t *Templates) Validate() error {
+	sample := Data{
+		APIEndpoint: "apiserver.eu-west-1.elb.amazonaws.com",
+		BaseDomain:  "example.com",
+		BastionIP:   "192.0.2.1",
+		BastionIPv6: "2001:db8::1",
+		ClusterName: "cluster",
+	}
+	zone := fmt.Sprintf("%s.%s", sample.ClusterName, sample.BaseDomain)
+
+	if t.Delegation.TTL <= 0 {
+		return errors.New("delegation TTL must be positive")
+	}
+
+	if t.API.TTL < 0 {
+		return errors.New("api record TTL must not be negative")
+	}
+
+	apiName, err := t.APIName(sample)
+	if err != nil {
+		return err
+	}
+	if !inZone(apiName, zone) {
+		return errors.Errorf("api record name %q is not part of the workload cluster zone", t.API.Name)
+	}
+
+	for _, r := range t.Records {
+		if !supportedTypes[r.Type] {
+			return errors.Errorf("record %q has unsupported type %q", r.Name, r.Type)
+		}
+		if r.TTL <= 0 {
+			return errors.Errorf("record %q must have a positive TTL", r.Name)
+		}
+		if len(r.Values) == 0 {
+			return errors.Errorf("record %q has no values", r.Name)
+		}
+		if r.Type == "CNAME" && len(r.Values) != 1 {
+			return errors.Errorf("CNAME record %q must have exactly one value", r.Name)
+		}
+	}
+
+	rendered, err := t.RenderRecords(sample)
+	if err != nil {
+		return err
+	}
+	for _, r := range rendered {
+		if !inZone(r.Name, zone) {
+			return errors.Errorf("record name %q is not part of the workload cluster zone", r.Name)
+		}
+	}
+
+	return nil
+}
+
+// APIName renders the name of the `api` record.
+func (t *Templates) APIName(data Data) (string, error) {
+	name, err := render(t.API.Name, data)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("api record name must not be empty")
+	}
+	return fqdn(name), nil
+}
+
+// APITTL returns the TTL of the `api` CNAME record, the default TTL if the template doesn't set one.
+func (t *Templates) APITTL() int64 {
+	if t.API.TTL == 0 {
+		return defaultTTL
+	}
+	return t.API.TTL
+}
+
+// DelegationTTL returns the TTL of the delegation records in the parent zone.
+func (t *Templates) DelegationTTL() int64 {
+	return t.Delegation.TTL
+}
+
+// RenderRecords renders the record templates. Records with an empty name or value are skipped.
+func (t *Templates) RenderRecords(data Data) ([]Record, error) {
+	var records []Record
+	for _, r := range t.Records {
+		name, err := render(r.Name, data)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+
+		record := Record{
+			Name: fqdn(name),
+			Type: r.Type,
+			TTL:  r.TTL,
+		}
+		for _, v := range r.Values {
+			value, err := render(v, data)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				break
+			}
+			record.Values = append(record.Values, value)
+		}
+		if len(record.Values) != len(r.Values) {
+			// e.g. bastion record without a bastion machine
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func render(text string, data Data) (string, error) {
+	tmpl, err := template.New("record").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse record template %q", text)
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render record template %q", text)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func inZone(name, zone string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ServiceName is the name of the operator in traces.
+	ServiceName = "dns-operator-aws"
+
+	tracerName = "github.com/giantswarm/dns-operator-aws"
+
+	tracesPath    = "/v1/traces"
+	exportTimeout = 10 * time.Second
+)
+
+// Setup configures the global tracer provider to export spans with OTLP over HTTP to the endpoint,
+// e.g. `http://otel-collector:4318`. Without an endpoint tracing stays disabled and spans are no-ops.
+// The sample ratio has to be between 0 and 1. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, endpoint string, sampleRatio float64) (func(context.Context) error, error) {
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, errors.Errorf("invalid tracing sample ratio %v, expected a value between 0 and 1", sampleRatio)
+	}
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") {
+		return nil, errors.Errorf("invalid tracing endpoint %q, expected http(s)://<host>:<port>", endpoint)
+	}
+
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpointURL.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(endpointURL.Path, "/") + tracesPath),
+		otlptracehttp.WithTimeout(exportTimeout),
+	}
+	if endpointURL.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span of the operator as child of the span in ctx.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan records the error, if any, on the span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
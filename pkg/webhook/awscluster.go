@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gsannotations "github.com/giantswarm/k8smetadata/pkg/annotation"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	capa "sigs.k8s.io/cluster-api-provider-aws/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/giantswarm/dns-operator-aws/pkg/cloud/scope"
+	"github.com/giantswarm/dns-operator-aws/pkg/key"
+)
+
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-awscluster,mutating=false,failurePolicy=ignore,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=awsclusters,verbs=create;update,versions=v1beta1,name=validation.awscluster.dns-operator-aws.giantswarm.io,admissionReviewVersions=v1
+
+// AWSClusterValidator validates the DNS annotations of AWSClusters.
+type AWSClusterValidator struct{}
+
+var _ admission.CustomValidator = &AWSClusterValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of AWSClusters with the manager's webhook server.
+func (v *AWSClusterValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&capa.AWSCluster{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate rejects AWSClusters with invalid DNS annotations.
+func (v *AWSClusterValidator) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	cluster, ok := obj.(*capa.AWSCluster)
+	if !ok {
+		return errors.Errorf("expected an AWSCluster but got %T", obj)
+	}
+
+	_, errs := scope.ParseClusterAnnotations(cluster.GetAnnotations())
+	return toInvalid(cluster, errs)
+}
+
+// ValidateUpdate rejects changed DNS annotations with invalid values and changes of the DNS mode
+// which are not requested as migration.
+// Annotations which didn't change are not validated again, so existing clusters with invalid
+// annotations can still be updated, e.g. by CAPA.
+func (v *AWSClusterValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) error {
+	oldCluster, ok := oldObj.(*capa.AWSCluster)
+	if !ok {
+		return errors.Errorf("expected an AWSCluster but got %T", oldObj)
+	}
+	newCluster, ok := newObj.(*capa.AWSCluster)
+	if !ok {
+		return errors.Errorf("expected an AWSCluster but got %T", newObj)
+	}
+
+	oldAnnotations := oldCluster.GetAnnotations()
+	newAnnotations := newCluster.GetAnnotations()
+
+	var errs field.ErrorList
+	parsed, parseErrs := scope.ParseClusterAnnotations(newAnnotations)
+	for _, err := range parseErrs {
+		annotation := annotationKey(err.Field)
+		if oldValue, ok := oldAnnotations[annotation]; ok && oldValue == newAnnotations[annotation] {
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	// the zone is only replaced when the migration is requested, see the DNSModeMigrated condition
+	if dnsMode(oldAnnotations) != dnsMode(newAnnotations) && !parsed.DNSModeMigration {
+		errs = append(errs, field.Forbidden(
+			field.NewPath("metadata", "annotations").Key(gsannotations.AWSDNSMode),
+			fmt.Sprintf("the DNS mode of an existing cluster can only be changed with the %s annotation", key.DNSModeMigrationAnnotation),
+		))
+	}
+
+	return toInvalid(newCluster, errs)
+}
+
+// ValidateDelete allows all deletions.
+func (v *AWSClusterValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// dnsMode returns the effective DNS mode given by the annotations, clusters without the annotation are public.
+func dnsMode(annotations map[string]string) string {
+	if mode := annotations[gsannotations.AWSDNSMode]; mode != "" {
+		return mode
+	}
+	return key.DNSModePublic
+}
+
+// annotationKey returns the annotation of a field path like `metadata.annotations[<key>]`.
+func annotationKey(path string) string {
+	prefix := field.NewPath("metadata", "annotations").String() + "["
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, prefix), "]")
+}
+
+func toInvalid(cluster *capa.AWSCluster, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(capa.GroupVersion.WithKind("AWSCluster").GroupKind(), cluster.Name, errs)
+}